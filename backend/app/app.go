@@ -0,0 +1,231 @@
+// Package app builds the application from its configuration: it owns the
+// database and Redis connections, the cache, the JWT signing keys and the
+// HTTP handlers, metrics and tracing, so several instances can live side by side in one process.
+package app
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/cache"
+	"instagram-backend/config"
+	"instagram-backend/handlers"
+	"instagram-backend/jobs"
+	"instagram-backend/keys"
+	"instagram-backend/mailer"
+	"instagram-backend/metrics"
+	"instagram-backend/oidc"
+	"instagram-backend/repository"
+	"instagram-backend/service"
+	"instagram-backend/tracing"
+	"log/slog"
+	"net/smtp"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// App is everything a server needs, built once from a Config.
+type App struct {
+	Config   *config.Config
+	DB       *gorm.DB
+	Redis    *redis.Client
+	Cache    *cache.Cache
+	Keys     *keys.KeySet
+	Mailer   mailer.Mailer
+	Logger   *slog.Logger
+	Metrics  *metrics.Metrics
+	Tracer   trace.TracerProvider
+	Handlers *Handlers
+
+	// Repositories and Services are built on DB; handlers get them rather
+	// than the connection itself.
+	Repositories *repository.Repositories
+	Services     *service.Services
+
+	// stopTracing flushes and stops the tracer provider New started.
+	stopTracing func(context.Context) error
+}
+
+// Option changes how NewWithClients builds an App.
+type Option func(*App)
+
+// WithLogger logs through logger rather than the default logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *App) { a.Logger = logger }
+}
+
+// WithMailer sends email through m instead of the mailer cfg.Mail selects.
+func WithMailer(m mailer.Mailer) Option {
+	return func(a *App) { a.Mailer = m }
+}
+
+// WithTracerProvider records spans with tp instead of the provider
+// cfg.Tracing selects.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(a *App) { a.Tracer = tp }
+}
+
+// Handlers groups the HTTP handlers the router mounts.
+type Handlers struct {
+	Auth         *handlers.AuthHandler
+	Post         *handlers.PostHandler
+	OIDC         *handlers.OIDCHandler
+	Category     *handlers.CategoryHandler
+	Page         *handlers.PageHandler
+	Moderation   *handlers.ModerationHandler
+	Admin        *handlers.AdminHandler
+	Verification *handlers.VerificationHandler
+}
+
+// New connects to the database, migrating it if configured to, and to Redis,
+// then builds the App on top of them.
+func New(cfg *config.Config) (*App, error) {
+	db, err := config.SetupDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rdb, err := config.SetupRedis(cfg.Redis)
+	if err != nil {
+		closeDB(db)
+		return nil, err
+	}
+
+	a, err := NewWithClients(cfg, db, rdb)
+	if err != nil {
+		rdb.Close()
+		closeDB(db)
+		return nil, err
+	}
+	return a, nil
+}
+
+// NewWithClients builds an App on connections the caller has already opened,
+// such as an in-memory database and Redis in tests.
+func NewWithClients(cfg *config.Config, db *gorm.DB, rdb *redis.Client, opts ...Option) (*App, error) {
+	ks, err := config.SetupKeys(cfg, db)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	m := metrics.New()
+	if err := m.Register(metrics.DBStats(sqlDB, "main"), metrics.RedisPool(rdb)); err != nil {
+		return nil, err
+	}
+
+	repos := repository.NewGorm(db)
+	a := &App{
+		Config:       cfg,
+		DB:           db,
+		Redis:        rdb,
+		Repositories: repos,
+		Services:     service.New(repos),
+		Cache: cache.New(rdb, cache.Options{
+			PostPrefix: cfg.Cache.PostPrefix,
+			UserPrefix: cfg.Cache.UserPrefix,
+			Expiration: cfg.Cache.Expiration,
+			Metrics:    m,
+		}),
+		Keys:    ks,
+		Mailer:  newMailer(cfg.Mail),
+		Logger:  slog.Default(),
+		Metrics: m,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.Tracer == nil {
+		a.Tracer, a.stopTracing, err = tracing.NewProvider(context.Background(), cfg.TracingOptions())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tracing.InstrumentDB(db, a.Tracer); err != nil {
+		return nil, err
+	}
+	if err := tracing.InstrumentRedis(rdb, a.Tracer); err != nil {
+		return nil, err
+	}
+
+	a.Handlers = a.newHandlers()
+	return a, nil
+}
+
+func (a *App) newHandlers() *Handlers {
+	auth := handlers.NewAuthHandler(a.Config, a.Repositories, a.Services, a.Cache, a.Mailer, a.Keys, a.Metrics,
+		func(ctx context.Context, exportID uint) {
+			jobs.BuildExport(ctx, a.DB, a.Config.Retention, exportID)
+		},
+	)
+	return &Handlers{
+		Auth: auth,
+		Post: handlers.NewPostHandler(a.Config, a.Repositories, a.Services, a.Cache, a.Metrics,
+			handlers.NewContentFilter(a.Config.ContentFilter, a.DB),
+			handlers.RequireVerifiedSellerForPurchaseOptions,
+			handlers.RequireSellerTwoFactor(a.Repositories.Settings),
+		),
+		OIDC: handlers.NewOIDCHandler(auth,
+			newOIDCRegistry(a.Config.OIDC),
+			&oidc.RedisStateStore{Client: a.Redis},
+		),
+		Category:     handlers.NewCategoryHandler(a.Repositories),
+		Page:         handlers.NewPageHandler(a.Config, a.Repositories),
+		Moderation:   handlers.NewModerationHandler(a.Config, a.Repositories, a.Cache),
+		Admin:        handlers.NewAdminHandler(a.Config, a.Repositories, auth),
+		Verification: handlers.NewVerificationHandler(a.Repositories, a.Cache),
+	}
+}
+
+// newMailer returns the mailer cfg.Driver selects. The log mailer is the
+// default so local setups work without an SMTP server.
+func newMailer(cfg config.MailConfig) mailer.Mailer {
+	if cfg.Driver != "smtp" {
+		return mailer.LogMailer{}
+	}
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return &mailer.SMTPMailer{
+		Addr: cfg.SMTPHost + ":" + cfg.SMTPPort,
+		From: cfg.From,
+		Auth: auth,
+	}
+}
+
+func newOIDCRegistry(cfg config.OIDCConfig) *oidc.Registry {
+	configs := make([]oidc.Config, len(cfg.Providers))
+	for i, p := range cfg.Providers {
+		configs[i] = oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}
+	}
+	return oidc.NewRegistry(configs...)
+}
+
+// Close flushes pending spans and closes the Redis and database connections.
+func (a *App) Close() error {
+	var err error
+	if a.stopTracing != nil {
+		err = a.stopTracing(context.Background())
+	}
+	return errors.Join(err, a.Redis.Close(), closeDB(a.DB))
+}
+
+func closeDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
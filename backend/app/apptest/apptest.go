@@ -0,0 +1,156 @@
+// Package apptest runs the whole application in-process for integration
+// tests: the real router and handlers on an in-memory SQLite database and a
+// miniredis server, one isolated pair per test.
+package apptest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"instagram-backend/app"
+	"instagram-backend/config"
+	"instagram-backend/logging"
+	"instagram-backend/mailer"
+	"instagram-backend/models"
+	"instagram-backend/router"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// databases numbers the in-memory databases.
+var databases atomic.Int64
+
+// Server is a running application and the stores behind it.
+type Server struct {
+	t      *testing.T
+	App    *app.App
+	Router *gin.Engine
+	DB     *gorm.DB
+	Redis  *miniredis.Miniredis
+	// Outbox holds the email the application sent.
+	Outbox *mailer.Outbox
+	// Logs holds what the application logged.
+	Logs *Logs
+	// Spans holds the spans the application recorded.
+	Spans *tracetest.InMemoryExporter
+
+	// seq numbers the records factories create so they stay unique.
+	seq int
+}
+
+// Config returns the configuration New starts from: development defaults
+// with exports written to a temporary directory and no throttling to speak
+// of.
+func Config(t *testing.T) *config.Config {
+	cfg := config.Default()
+	cfg.Environment = "development"
+	cfg.Database.URL = "sqlite"
+	cfg.Server.RequestsPerSecond = 1000
+	cfg.JWT.KeyEncryptionKey = base64.StdEncoding.EncodeToString(make([]byte, 32))
+	cfg.Retention.ExportDir = t.TempDir()
+	return cfg
+}
+
+// New starts an application for t, applying configure to its configuration
+// first. Everything is torn down when t ends.
+func New(t *testing.T, configure ...func(*config.Config)) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := Config(t)
+	for _, f := range configure {
+		f(cfg)
+	}
+
+	// Shared cache lets every connection in the pool see the same in-memory
+	// database; the number keeps it private to this server.
+	dsn := fmt.Sprintf("file:apptest%d?mode=memory&cache=shared&_pragma=foreign_keys(1)", databases.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	// The SQL migrations are written for Postgres, so tests build the schema
+	// with AutoMigrate instead.
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	outbox := &mailer.Outbox{}
+	logs := &Logs{}
+	spans := tracetest.NewInMemoryExporter()
+	application, err := app.NewWithClients(cfg, db, redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+		app.WithMailer(outbox),
+		app.WithLogger(logging.New(logs, logging.Options{Level: slog.LevelDebug})),
+		app.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))),
+	)
+	if err != nil {
+		t.Fatalf("build app: %v", err)
+	}
+	t.Cleanup(func() { application.Close() })
+
+	return &Server{
+		t:      t,
+		App:    application,
+		Router: router.SetupRouter(application),
+		DB:     db,
+		Redis:  mr,
+		Outbox: outbox,
+		Logs:   logs,
+		Spans:  spans,
+	}
+}
+
+// T returns the test the server belongs to.
+func (s *Server) T() *testing.T {
+	return s.t
+}
+
+// Logs collects the application's JSON log lines.
+type Logs struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *Logs) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// Entries returns the records logged so far with message msg, or every
+// record if msg is empty.
+func (l *Logs) Entries(msg string) []map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(l.buf.Bytes()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if msg == "" || entry["msg"] == msg {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
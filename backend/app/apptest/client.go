@@ -0,0 +1,139 @@
+package apptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"instagram-backend/models"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// Client sends requests to a Server.
+type Client struct {
+	s *Server
+	// Header is sent with every request.
+	Header http.Header
+}
+
+// Anonymous returns a client that isn't signed in.
+func (s *Server) Anonymous() *Client {
+	return &Client{s: s, Header: http.Header{}}
+}
+
+// As returns a client signed in as user.
+func (s *Server) As(user *models.User) *Client {
+	s.t.Helper()
+	c := s.Anonymous()
+	c.Header.Set("Authorization", "Bearer "+s.Login(user))
+	return c
+}
+
+// WithAPIKey returns a client that authenticates with an API key.
+func (s *Server) WithAPIKey(key string) *Client {
+	c := s.Anonymous()
+	c.Header.Set("X-API-Key", key)
+	return c
+}
+
+// Login signs user in through the API, finishing two-step login with a
+// code from their authenticator secret, and returns the token.
+func (s *Server) Login(user *models.User) string {
+	s.t.Helper()
+	var login struct {
+		Token    string `json:"token"`
+		MFAToken string `json:"mfaToken"`
+	}
+	s.Anonymous().Post("/api/v1/login", map[string]string{
+		"email":    user.Email,
+		"password": Password,
+	}).Expect(http.StatusOK).Decode(&login)
+	if login.MFAToken == "" {
+		return login.Token
+	}
+
+	code, err := totp.GenerateCode(user.TOTPSecret, time.Now())
+	if err != nil {
+		s.t.Fatalf("generate TOTP code: %v", err)
+	}
+	s.Anonymous().Post("/api/v1/login/2fa", map[string]string{
+		"mfaToken": login.MFAToken,
+		"code":     code,
+	}).Expect(http.StatusOK).Decode(&login)
+	return login.Token
+}
+
+func (c *Client) Get(path string) *Response {
+	return c.Do(http.MethodGet, path, nil)
+}
+
+func (c *Client) Post(path string, body interface{}) *Response {
+	return c.Do(http.MethodPost, path, body)
+}
+
+func (c *Client) Put(path string, body interface{}) *Response {
+	return c.Do(http.MethodPut, path, body)
+}
+
+func (c *Client) Delete(path string) *Response {
+	return c.Do(http.MethodDelete, path, nil)
+}
+
+// Do sends a request through the router. A string body is sent as is;
+// anything else but nil is encoded as JSON.
+func (c *Client) Do(method, path string, body interface{}) *Response {
+	c.s.t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			c.s.t.Fatalf("encode %s %s body: %v", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	c.s.Router.ServeHTTP(w, req)
+	return &Response{ResponseRecorder: w, t: c.s.t, request: method + " " + path}
+}
+
+// Response is what the router sent back.
+type Response struct {
+	*httptest.ResponseRecorder
+	t       *testing.T
+	request string
+}
+
+// Expect fails the test unless the response has status.
+func (r *Response) Expect(status int) *Response {
+	r.t.Helper()
+	if r.Code != status {
+		r.t.Fatalf("%s: status = %d, want %d, body %s", r.request, r.Code, status, r.Body)
+	}
+	return r
+}
+
+// Decode decodes the JSON body into v.
+func (r *Response) Decode(v interface{}) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("%s: decode body %s: %v", r.request, r.Body, err)
+	}
+}
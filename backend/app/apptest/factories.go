@@ -0,0 +1,145 @@
+package apptest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"instagram-backend/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password is the password of every user the factories create.
+const Password = "password123"
+
+// passwordHash hashes Password once, at the lowest cost, to keep tests fast.
+var passwordHash = sync.OnceValue(func() string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+	return string(hash)
+})
+
+func (s *Server) next() int {
+	s.seq++
+	return s.seq
+}
+
+func (s *Server) create(value interface{}) {
+	s.t.Helper()
+	if err := s.DB.Create(value).Error; err != nil {
+		s.t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// CreateUser creates a buyer with a verified email. Options adjust the user
+// before it is saved.
+func (s *Server) CreateUser(opts ...func(*models.User)) *models.User {
+	s.t.Helper()
+	n := s.next()
+	now := time.Now()
+	user := &models.User{
+		Username:        fmt.Sprintf("user%d", n),
+		Email:           fmt.Sprintf("user%d@example.com", n),
+		Password:        passwordHash(),
+		Name:            fmt.Sprintf("User %d", n),
+		Role:            "buyer",
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	for _, opt := range opts {
+		opt(user)
+	}
+	s.create(user)
+	return user
+}
+
+// CreateSeller creates a seller with a category of their own, their username
+// namespaced under it as registration requires.
+func (s *Server) CreateSeller(opts ...func(*models.User)) *models.User {
+	s.t.Helper()
+	category := s.CreateCategory()
+	return s.CreateUser(append([]func(*models.User){func(u *models.User) {
+		u.Role = "seller"
+		u.Username = fmt.Sprintf("%s/seller%d", category.Slug, s.next())
+		u.CategoryID = &category.ID
+	}}, opts...)...)
+}
+
+// CreateAdmin creates an admin.
+func (s *Server) CreateAdmin(opts ...func(*models.User)) *models.User {
+	s.t.Helper()
+	return s.CreateUser(append([]func(*models.User){func(u *models.User) {
+		u.Role = "admin"
+	}}, opts...)...)
+}
+
+// CreateCategory creates a storefront category.
+func (s *Server) CreateCategory(opts ...func(*models.Category)) *models.Category {
+	s.t.Helper()
+	n := s.next()
+	category := &models.Category{
+		Slug: fmt.Sprintf("category%d", n),
+		Name: fmt.Sprintf("Category %d", n),
+	}
+	for _, opt := range opts {
+		opt(category)
+	}
+	s.create(category)
+	return category
+}
+
+// CreatePost creates a feed post by author with one image.
+func (s *Server) CreatePost(author *models.User, opts ...func(*models.Post)) *models.Post {
+	s.t.Helper()
+	n := s.next()
+	post := &models.Post{
+		Caption:     fmt.Sprintf("Post %d", n),
+		UserID:      author.ID,
+		ContentType: "feed",
+		PostImages:  []models.PostImage{{ImageURL: fmt.Sprintf("https://example.com/%d.jpg", n)}},
+	}
+	for _, opt := range opts {
+		opt(post)
+	}
+	s.create(post)
+	return post
+}
+
+// CreateComment creates a published comment by author on post.
+func (s *Server) CreateComment(post *models.Post, author *models.User, opts ...func(*models.Comment)) *models.Comment {
+	s.t.Helper()
+	comment := &models.Comment{
+		Content: fmt.Sprintf("Comment %d", s.next()),
+		UserID:  author.ID,
+		PostID:  post.ID,
+		Status:  models.CommentPublished,
+	}
+	for _, opt := range opts {
+		opt(comment)
+	}
+	s.create(comment)
+	return comment
+}
+
+// Subscribe makes subscriber an approved subscriber of seller.
+func (s *Server) Subscribe(subscriber, seller *models.User) *models.Subscription {
+	s.t.Helper()
+	now := time.Now()
+	subscription := &models.Subscription{
+		SubscriberID: subscriber.ID,
+		SellerID:     seller.ID,
+		Status:       models.SubscriptionApproved,
+		ApprovedAt:   &now,
+	}
+	s.create(subscription)
+	return subscription
+}
+
+// Create saves any other record, failing the test if it can't.
+func (s *Server) Create(value interface{}) {
+	s.t.Helper()
+	s.create(value)
+}
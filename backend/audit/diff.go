@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Change is a field's value before and after an update.
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// redacted stands in for values of fields that are never serialized, such as
+// password hashes, so the log shows that they changed but not to what.
+const redacted = "[redacted]"
+
+// Diff compares two values of the same struct type field by field and returns
+// the changed fields keyed by their JSON names. Embedded structs such as
+// gorm.Model and bookkeeping timestamps are skipped.
+func Diff(before, after interface{}) map[string]Change {
+	b, a := reflect.Indirect(reflect.ValueOf(before)), reflect.Indirect(reflect.ValueOf(after))
+	if b.Kind() != reflect.Struct || b.Type() != a.Type() {
+		return nil
+	}
+
+	changes := make(map[string]Change)
+	t := b.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous || !field.IsExported() || field.Name == "CreatedAt" || field.Name == "UpdatedAt" {
+			continue
+		}
+		// Associations are audited on their own
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			continue
+		}
+
+		from, to := b.Field(i).Interface(), a.Field(i).Interface()
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			changes[lowerFirst(field.Name)] = Change{From: redacted, To: redacted}
+		case "":
+			changes[field.Name] = Change{From: from, To: to}
+		default:
+			changes[name] = Change{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -3,40 +3,65 @@ package cache
 import (
 	"context"
 	"encoding/json"
-	"instagram-backend/config"
+	"errors"
+	"instagram-backend/metrics"
 	"instagram-backend/models"
-	"os"
 	"strconv"
 	"time"
-)
 
-var (
-	PostCachePrefix    = os.Getenv("REDIS_POST_CACHE_PREFIX")
-	UserCachePrefix    = os.Getenv("REDIS_USER_CACHE_PREFIX")
-	DefaultExpiration = time.Duration(func() int {
-		exp, err := strconv.Atoi(os.Getenv("REDIS_CACHE_EXPIRATION"))
-		if err != nil || exp <= 0 {
-			return 30 // Default to 30 minutes if not set or invalid
-		}
-		return exp
-	}()) * time.Minute
+	"github.com/redis/go-redis/v9"
 )
 
+// Options configures a Cache.
+type Options struct {
+	PostPrefix string
+	UserPrefix string
+	Expiration time.Duration
+	// Metrics counts hits and misses; it may be nil.
+	Metrics *metrics.Metrics
+}
+
+// Cache keeps posts and user profiles in Redis.
+type Cache struct {
+	client *redis.Client
+	opts   Options
+}
+
+// New returns a Cache storing entries in client.
+func New(client *redis.Client, opts Options) *Cache {
+	if opts.Expiration <= 0 {
+		opts.Expiration = 30 * time.Minute // Default value
+	}
+	return &Cache{client: client, opts: opts}
+}
+
+// Redis returns the underlying client, for keys the cache doesn't manage.
+func (c *Cache) Redis() *redis.Client {
+	return c.client
+}
+
+func (c *Cache) postKey(postID uint) string {
+	return c.opts.PostPrefix + strconv.FormatUint(uint64(postID), 10)
+}
+
+func (c *Cache) userKey(userID uint) string {
+	return c.opts.UserPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
 // CachePost caches a post with its associations
-func CachePost(ctx context.Context, post *models.Post) error {
-	redis := config.GetRedisClient()
+func (c *Cache) CachePost(ctx context.Context, post *models.Post) error {
 	data, err := json.Marshal(post)
 	if err != nil {
 		return err
 	}
 
-	return redis.Set(ctx, PostCachePrefix+string(post.ID), data, DefaultExpiration).Err()
+	return c.client.Set(ctx, c.postKey(post.ID), data, c.opts.Expiration).Err()
 }
 
 // GetCachedPost retrieves a cached post
-func GetCachedPost(ctx context.Context, postID uint) (*models.Post, error) {
-	redis := config.GetRedisClient()
-	data, err := redis.Get(ctx, PostCachePrefix+string(postID)).Bytes()
+func (c *Cache) GetCachedPost(ctx context.Context, postID uint) (*models.Post, error) {
+	data, err := c.client.Get(ctx, c.postKey(postID)).Bytes()
+	c.recordLookup(metrics.CachePost, err)
 	if err != nil {
 		return nil, err
 	}
@@ -49,21 +74,27 @@ func GetCachedPost(ctx context.Context, postID uint) (*models.Post, error) {
 	return &post, nil
 }
 
+// GetCachedPage retrieves a cached page of the feed.
+func (c *Cache) GetCachedPage(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	c.recordLookup(metrics.CachePostsPage, err)
+	return data, err
+}
+
 // CacheUser caches a user profile
-func CacheUser(ctx context.Context, user *models.User) error {
-	redis := config.GetRedisClient()
+func (c *Cache) CacheUser(ctx context.Context, user *models.User) error {
 	data, err := json.Marshal(user)
 	if err != nil {
 		return err
 	}
 
-	return redis.Set(ctx, UserCachePrefix+string(user.ID), data, DefaultExpiration).Err()
+	return c.client.Set(ctx, c.userKey(user.ID), data, c.opts.Expiration).Err()
 }
 
 // GetCachedUser retrieves a cached user profile
-func GetCachedUser(ctx context.Context, userID uint) (*models.User, error) {
-	redis := config.GetRedisClient()
-	data, err := redis.Get(ctx, UserCachePrefix+string(userID)).Bytes()
+func (c *Cache) GetCachedUser(ctx context.Context, userID uint) (*models.User, error) {
+	data, err := c.client.Get(ctx, c.userKey(userID)).Bytes()
+	c.recordLookup(metrics.CacheUser, err)
 	if err != nil {
 		return nil, err
 	}
@@ -76,12 +107,23 @@ func GetCachedUser(ctx context.Context, userID uint) (*models.User, error) {
 	return &user, nil
 }
 
+// recordLookup counts a lookup whose result was err as a hit or a miss.
+// Errors other than a missing key are neither.
+func (c *Cache) recordLookup(cache string, err error) {
+	switch {
+	case err == nil:
+		c.opts.Metrics.CacheLookup(cache, true)
+	case errors.Is(err, redis.Nil):
+		c.opts.Metrics.CacheLookup(cache, false)
+	}
+}
+
 // InvalidatePostCache removes a post from cache
-func InvalidatePostCache(ctx context.Context, postID uint) error {
-	return config.GetRedisClient().Del(ctx, PostCachePrefix+string(postID)).Err()
+func (c *Cache) InvalidatePostCache(ctx context.Context, postID uint) error {
+	return c.client.Del(ctx, c.postKey(postID)).Err()
 }
 
 // InvalidateUserCache removes a user from cache
-func InvalidateUserCache(ctx context.Context, userID uint) error {
-	return config.GetRedisClient().Del(ctx, UserCachePrefix+string(userID)).Err()
-}
\ No newline at end of file
+func (c *Cache) InvalidateUserCache(ctx context.Context, userID uint) error {
+	return c.client.Del(ctx, c.userKey(userID)).Err()
+}
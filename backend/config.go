@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"instagram-backend/config"
+	"os"
+)
+
+const configUsage = `usage: config <command>
+
+commands:
+  print [yaml|env]  print the effective configuration with secrets redacted,
+                    as YAML by default`
+
+// runConfig implements the config subcommand.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" || len(args) > 2 {
+		return errors.New(configUsage)
+	}
+	format := "yaml"
+	if len(args) == 2 {
+		format = args[1]
+	}
+
+	// Print what loaded even when it is invalid, then say what is wrong
+	cfg, loadErr := config.Load()
+	if err := cfg.Print(os.Stdout, format); err != nil {
+		return err
+	}
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr)
+		return loadErr
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Config holds every setting the app is built from. Load fills it from
+// defaults, an optional YAML or TOML file and environment variables, in that
+// order of precedence.
+//
+// Field tags describe where each setting comes from:
+//
+//	config:"name"  key in the config file, nested under its section's key
+//	env:"NAME"     environment variable; NAME_FILE may name a file holding it
+//	unit:"s"       unit of a bare number given for a duration: s, m, h or d
+//	secret:"true"  redacted when the configuration is printed
+type Config struct {
+	// Environment is "development", "staging" or "production".
+	Environment string `config:"environment" env:"ENVIRONMENT"`
+
+	Log           LogConfig           `config:"log"`
+	Metrics       MetricsConfig       `config:"metrics"`
+	Tracing       TracingConfig       `config:"tracing"`
+	Server        ServerConfig        `config:"server"`
+	Database      DatabaseConfig      `config:"database"`
+	Redis         RedisConfig         `config:"redis"`
+	Cache         CacheConfig         `config:"cache"`
+	JWT           JWTConfig           `config:"jwt"`
+	Auth          AuthConfig          `config:"auth"`
+	Mail          MailConfig          `config:"mail"`
+	OIDC          OIDCConfig          `config:"oidc"`
+	ContentFilter ContentFilterConfig `config:"content_filter"`
+	Moderation    ModerationConfig    `config:"moderation"`
+	Retention     RetentionConfig     `config:"retention"`
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error. Debug includes every database
+	// query.
+	Level string `config:"level" env:"LOG_LEVEL"`
+	// Format is json or, easier to read on a terminal, text.
+	Format string `config:"format" env:"LOG_FORMAT"`
+	// SuccessSampleRate is the share of successful requests logged in
+	// production, between 0 and 1. Failed requests are always logged, as are
+	// all requests elsewhere.
+	SuccessSampleRate float64 `config:"success_sample_rate" env:"LOG_SUCCESS_SAMPLE_RATE"`
+}
+
+type MetricsConfig struct {
+	// Token, if set, must be sent as a bearer token to read /metrics.
+	Token string `config:"token" env:"METRICS_TOKEN" secret:"true"`
+}
+
+type TracingConfig struct {
+	// Exporter is "none", which records no spans, or "otlp", which sends them
+	// to an OpenTelemetry collector over OTLP/HTTP.
+	Exporter string `config:"exporter" env:"TRACING_EXPORTER"`
+	// Endpoint is the collector's traces URL, e.g.
+	// http://localhost:4318/v1/traces. When empty the exporter reads the
+	// standard OTEL_EXPORTER_OTLP_* variables.
+	Endpoint    string `config:"endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	ServiceName string `config:"service_name" env:"OTEL_SERVICE_NAME"`
+	// SampleRatio is the share of new traces recorded, between 0 and 1.
+	// Requests carrying a traceparent follow the caller's decision.
+	SampleRatio float64 `config:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type ServerConfig struct {
+	Port string `config:"port" env:"PORT"`
+	// BaseURL is where the app is reachable, for links in emails and pages.
+	BaseURL      string        `config:"base_url" env:"APP_BASE_URL"`
+	ReadTimeout  time.Duration `config:"read_timeout" env:"READ_TIMEOUT"`
+	WriteTimeout time.Duration `config:"write_timeout" env:"WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `config:"idle_timeout" env:"IDLE_TIMEOUT"`
+	// RequestsPerSecond throttles the feed and other expensive reads.
+	RequestsPerSecond int `config:"requests_per_second" env:"RATE_LIMIT_REQUESTS_PER_SECOND"`
+}
+
+type DatabaseConfig struct {
+	URL string `config:"url" env:"DATABASE_URL" secret:"true"`
+	// MigrateOnStart applies pending migrations when the server starts.
+	// Deployments that migrate separately, with the migrate command, can
+	// turn it off.
+	MigrateOnStart  bool          `config:"migrate_on_start" env:"MIGRATE_ON_START"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	MaxOpenConns    int           `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" unit:"m"`
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" unit:"m"`
+}
+
+type RedisConfig struct {
+	Addr         string        `config:"addr" env:"REDIS_URL"`
+	Password     string        `config:"password" env:"REDIS_PASSWORD" secret:"true"`
+	PoolSize     int           `config:"pool_size" env:"REDIS_POOL_SIZE"`
+	MinIdleConns int           `config:"min_idle_conns" env:"REDIS_MIN_IDLE_CONNS"`
+	MaxRetries   int           `config:"max_retries" env:"REDIS_MAX_RETRIES"`
+	DialTimeout  time.Duration `config:"dial_timeout" env:"REDIS_DIAL_TIMEOUT" unit:"s"`
+	ReadTimeout  time.Duration `config:"read_timeout" env:"REDIS_READ_TIMEOUT" unit:"s"`
+	WriteTimeout time.Duration `config:"write_timeout" env:"REDIS_WRITE_TIMEOUT" unit:"s"`
+	PoolTimeout  time.Duration `config:"pool_timeout" env:"REDIS_POOL_TIMEOUT" unit:"s"`
+}
+
+type CacheConfig struct {
+	PostPrefix string        `config:"post_prefix" env:"REDIS_POST_CACHE_PREFIX"`
+	UserPrefix string        `config:"user_prefix" env:"REDIS_USER_CACHE_PREFIX"`
+	Expiration time.Duration `config:"expiration" env:"REDIS_CACHE_EXPIRATION" unit:"m"`
+}
+
+type JWTConfig struct {
+	// SigningAlg is EdDSA or RS256.
+	SigningAlg          string        `config:"signing_alg" env:"JWT_SIGNING_ALG"`
+	KeyRotationInterval time.Duration `config:"key_rotation_interval" env:"JWT_KEY_ROTATION_INTERVAL"`
+	KeyPrePublish       time.Duration `config:"key_prepublish" env:"JWT_KEY_PREPUBLISH"`
+	// KeyOverlap must cover TokenLifetime, so retired keys still verify the
+	// tokens they signed.
+	KeyOverlap time.Duration `config:"key_overlap" env:"JWT_KEY_OVERLAP"`
+	// KeyEncryptionKey is 32 bytes, base64 encoded. It is required outside
+	// development.
+	KeyEncryptionKey string `config:"key_encryption_key" env:"JWT_KEY_ENCRYPTION_KEY" secret:"true"`
+	// TokenLifetime is how long login tokens, and the sessions behind them,
+	// last.
+	TokenLifetime time.Duration `config:"token_lifetime" env:"JWT_EXPIRY_DAYS" unit:"d"`
+}
+
+type AuthConfig struct {
+	EmailVerificationTTL time.Duration `config:"email_verification_ttl" env:"EMAIL_VERIFICATION_TTL_HOURS" unit:"h"`
+	// VerificationResendCooldown is the minimum time between verification
+	// emails.
+	VerificationResendCooldown time.Duration `config:"verification_resend_cooldown" env:"EMAIL_VERIFICATION_RESEND_COOLDOWN"`
+	UsernameChangeCooldown     time.Duration `config:"username_change_cooldown" env:"USERNAME_CHANGE_COOLDOWN_DAYS" unit:"d"`
+	// AccountDeletionGrace is how long a deleted account can still be
+	// restored.
+	AccountDeletionGrace time.Duration `config:"account_deletion_grace" env:"ACCOUNT_DELETION_GRACE_DAYS" unit:"d"`
+	// ImpersonationTTL is how long a support session opened by an admin lasts.
+	ImpersonationTTL time.Duration `config:"impersonation_ttl" env:"IMPERSONATION_TTL"`
+	TOTPIssuer       string        `config:"totp_issuer" env:"TOTP_ISSUER"`
+}
+
+type MailConfig struct {
+	// Driver is "log", which only logs messages, or "smtp".
+	Driver       string `config:"driver" env:"MAILER"`
+	SMTPHost     string `config:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `config:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `config:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `config:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From         string `config:"from" env:"MAIL_FROM"`
+}
+
+// OIDCConfig lists the social login providers. In the environment they are
+// named in OIDC_PROVIDERS (e.g. "google,apple") and each is configured with
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES,
+// which also override providers listed in the config file.
+type OIDCConfig struct {
+	Providers []OIDCProvider `config:"providers" env:"OIDC_PROVIDERS"`
+}
+
+type OIDCProvider struct {
+	Name         string   `config:"name"`
+	Issuer       string   `config:"issuer" env:"ISSUER"`
+	ClientID     string   `config:"client_id" env:"CLIENT_ID"`
+	ClientSecret string   `config:"client_secret" env:"CLIENT_SECRET" secret:"true"`
+	RedirectURL  string   `config:"redirect_url" env:"REDIRECT_URL"`
+	Scopes       []string `config:"scopes" env:"SCOPES"`
+}
+
+type ContentFilterConfig struct {
+	// WordListDir holds <locale>.txt word lists; empty uses the built-in ones.
+	WordListDir     string        `config:"wordlist_dir" env:"CONTENT_FILTER_WORDLIST_DIR"`
+	DefaultLocale   string        `config:"default_locale" env:"CONTENT_FILTER_DEFAULT_LOCALE"`
+	MaxLinks        int           `config:"max_links" env:"CONTENT_FILTER_MAX_LINKS"`
+	MaxRepeats      int           `config:"max_repeats" env:"CONTENT_FILTER_MAX_REPEATS"`
+	DuplicateWindow time.Duration `config:"duplicate_window" env:"CONTENT_FILTER_DUPLICATE_WINDOW"`
+}
+
+type ModerationConfig struct {
+	// ReportAutoHideThreshold is how many open reports hide content until a
+	// moderator looks at it.
+	ReportAutoHideThreshold int64 `config:"report_auto_hide_threshold" env:"REPORT_AUTO_HIDE_THRESHOLD"`
+}
+
+type RetentionConfig struct {
+	// Trash is how long deleted posts and comments can be restored.
+	Trash time.Duration `config:"trash" env:"TRASH_RETENTION_DAYS" unit:"d"`
+	// Audit is how long audit events are kept.
+	Audit time.Duration `config:"audit" env:"AUDIT_RETENTION_DAYS" unit:"d"`
+	// ExportDir is where finished data export archives are written.
+	ExportDir string `config:"export_dir" env:"EXPORT_DIR"`
+	// Export is how long a finished archive can be downloaded.
+	Export time.Duration `config:"export" env:"EXPORT_RETENTION_HOURS" unit:"h"`
+}
+
+// Default returns the configuration used for anything left unset. It has no
+// database URL: that has to be given.
+func Default() *Config {
+	return &Config{
+		Environment: "production",
+		Log: LogConfig{
+			Level:             "info",
+			Format:            "json",
+			SuccessSampleRate: 0.1,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "instagram-backend",
+			SampleRatio: 1,
+		},
+		Server: ServerConfig{
+			Port:              "8080",
+			BaseURL:           "http://localhost:8080",
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			RequestsPerSecond: 10,
+		},
+		Database: DatabaseConfig{
+			MigrateOnStart:  true,
+			MaxIdleConns:    25,
+			MaxOpenConns:    200,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 10 * time.Minute,
+		},
+		Redis: RedisConfig{
+			Addr: "localhost:6379",
+		},
+		Cache: CacheConfig{
+			Expiration: 30 * time.Minute,
+		},
+		JWT: JWTConfig{
+			SigningAlg:          "EdDSA",
+			KeyRotationInterval: 30 * 24 * time.Hour,
+			KeyPrePublish:       time.Hour,
+			KeyOverlap:          8 * 24 * time.Hour,
+			TokenLifetime:       7 * 24 * time.Hour,
+		},
+		Auth: AuthConfig{
+			EmailVerificationTTL:       48 * time.Hour,
+			VerificationResendCooldown: time.Minute,
+			UsernameChangeCooldown:     30 * 24 * time.Hour,
+			AccountDeletionGrace:       30 * 24 * time.Hour,
+			ImpersonationTTL:           time.Hour,
+			TOTPIssuer:                 "Instagram",
+		},
+		Mail: MailConfig{
+			Driver:   "log",
+			SMTPPort: "587",
+		},
+		ContentFilter: ContentFilterConfig{
+			DefaultLocale:   "en",
+			MaxRepeats:      2,
+			DuplicateWindow: 10 * time.Minute,
+		},
+		Moderation: ModerationConfig{
+			ReportAutoHideThreshold: 5,
+		},
+		Retention: RetentionConfig{
+			Trash:     30 * 24 * time.Hour,
+			Audit:     365 * 24 * time.Hour,
+			ExportDir: filepath.Join(os.TempDir(), "instagram-exports"),
+			Export:    72 * time.Hour,
+		},
+	}
+}
+
+// IsDevelopment reports whether the app runs on a developer's machine.
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "development"
+}
+
+// IsProduction reports whether the app serves real users.
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production"
+}
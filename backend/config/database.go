@@ -1,10 +1,11 @@
 package config
 
 import (
+	"context"
 	"fmt"
-	"instagram-backend/models"
-	"log"
-	"os"
+	"instagram-backend/logging"
+	"instagram-backend/migrations"
+	"log/slog"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -12,19 +13,14 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-var Db *gorm.DB
-
-func SetupDatabase() error {
-	var err error
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		dsn = "host=localhost user=postgres password=Tosif@123 dbname=instagram port=5432 sslmode=disable"
-	}
-	fmt.Println("DB Connection String:", dsn)
-
+// ConnectDatabase opens the database without touching the schema.
+func ConnectDatabase(cfg *Config) (*gorm.DB, error) {
 	// Configure connection pool and logging with optimized settings
-	Db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger:      logger.Default.LogMode(getLogLevel()),
+	db, err := gorm.Open(postgres.Open(cfg.Database.URL), &gorm.Config{
+		Logger: logging.GormLogger{
+			Level:         getLogLevel(cfg),
+			SlowThreshold: slowQueryThreshold,
+		},
 		PrepareStmt: true, // Enable prepared statement cache
 		NowFunc: func() time.Time { // Ensure consistent time handling
 			return time.Now().UTC()
@@ -33,64 +29,61 @@ func SetupDatabase() error {
 		DisableAutomaticPing:   true, // Disable automatic ping
 	})
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 
 	// Configure connection pool settings with optimized values
-	sqlDB, err := Db.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to get database instance: %v", err)
+		return nil, fmt.Errorf("failed to get database instance: %v", err)
 	}
 
-	// Set optimized connection pool parameters
-	sqlDB.SetMaxIdleConns(25)                  // Increased from 10
-	sqlDB.SetMaxOpenConns(200)                 // Increased from 100
-	sqlDB.SetConnMaxLifetime(30 * time.Minute) // Reduced from 1 hour for better resource management
-	sqlDB.SetConnMaxIdleTime(10 * time.Minute) // Add idle timeout
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
 
-	log.Println("Connected to database successfully")
+	slog.Info("Connected to database")
 
-	// Create channels for parallel migration processing
-	errorChan := make(chan error)
-	doneChan := make(chan bool)
-
-	// Run migrations in a separate goroutine
-	go func() {
-		log.Println("Running database migrations...")
-		err := Db.AutoMigrate(
-			&models.User{},
-			&models.Post{},
-			&models.Comment{},
-			&models.Like{},
-			&models.PostImage{},
-			&models.PurchaseOption{},
-			&models.Subscription{},
-		)
+	return db, nil
+}
 
-		if err != nil {
-			errorChan <- fmt.Errorf("failed to run migrations: %v", err)
-			return
-		}
-		doneChan <- true
-	}()
+// SetupDatabase connects to the database and, if cfg.Database.MigrateOnStart
+// is set, applies pending migrations.
+func SetupDatabase(cfg *Config) (*gorm.DB, error) {
+	db, err := ConnectDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Database.MigrateOnStart {
+		slog.Info("Skipping database migrations")
+		return db, nil
+	}
 
-	// Wait for migration completion or timeout
-	select {
-	case err := <-errorChan:
-		return err
-	case <-doneChan:
-		log.Println("Database migrations completed successfully")
-	case <-time.After(2 * time.Minute): // Add timeout for migrations
-		return fmt.Errorf("migration timeout after 2 minutes")
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %v", err)
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
 
-	return nil
+	slog.Info("Running database migrations")
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %v", err)
+	}
+	slog.Info("Database migrations completed", "applied", applied)
+	return db, nil
 }
 
-// getLogLevel returns the appropriate log level based on environment
-func getLogLevel() logger.LogLevel {
-	if os.Getenv("ENVIRONMENT") == "production" {
-		return logger.Silent
+// slowQueryThreshold is how long a query runs before it is logged as slow.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// getLogLevel returns how much GORM logs: in production only failed and slow
+// queries, elsewhere every query, which shows at the debug log level.
+func getLogLevel(cfg *Config) logger.LogLevel {
+	if cfg.IsProduction() {
+		return logger.Warn
 	}
 	return logger.Info
 }
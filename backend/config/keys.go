@@ -0,0 +1,42 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"instagram-backend/keys"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// KeyOptions returns the signing key options. Without a key encryption key,
+// which validation only allows in development, a fixed key is used with a
+// warning.
+func (c *Config) KeyOptions() keys.Options {
+	opts := keys.Options{
+		Algorithm:        c.JWT.SigningAlg,
+		RotationInterval: c.JWT.KeyRotationInterval,
+		PrePublish:       c.JWT.KeyPrePublish,
+		Overlap:          c.JWT.KeyOverlap,
+	}
+	if c.JWT.KeyEncryptionKey == "" {
+		slog.Warn("JWT_KEY_ENCRYPTION_KEY not set, using an insecure development key")
+		sum := sha256.Sum256([]byte("instagram-backend development signing keys"))
+		opts.EncryptionKey = sum[:]
+		return opts
+	}
+	opts.EncryptionKey, _ = base64.StdEncoding.DecodeString(c.JWT.KeyEncryptionKey)
+	return opts
+}
+
+// SetupKeys loads the JWT signing keys, creating the first one if needed.
+func SetupKeys(cfg *Config, db *gorm.DB) (*keys.KeySet, error) {
+	ks, err := keys.NewKeySet(context.Background(), db, cfg.KeyOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Loaded JWT signing keys", "count", len(ks.PublicKeys()))
+	return ks, nil
+}
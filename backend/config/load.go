@@ -0,0 +1,354 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Error lists everything wrong with a configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load reads the configuration from the file named by CONFIG_FILE, if any,
+// and the environment, on top of Default. Empty environment variables count
+// as unset. The returned Config is filled in as far as possible even when
+// the error, an *Error, reports problems.
+func Load() (*Config, error) {
+	return load(os.LookupEnv)
+}
+
+func load(lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	var problems []string
+
+	if path, _ := lookupEnv("CONFIG_FILE"); path != "" {
+		problems = append(problems, loadFile(cfg, path)...)
+	}
+	problems = append(problems, loadEnv(cfg, lookupEnv)...)
+	problems = append(problems, cfg.validate()...)
+
+	if len(problems) > 0 {
+		return cfg, &Error{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// setting is one field of Config with its tags.
+type setting struct {
+	// key is the dotted path of the setting in the config file.
+	key    string
+	env    string
+	unit   time.Duration
+	secret bool
+	value  reflect.Value
+}
+
+var units = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// settings flattens v, a pointer to a struct, into its settings in
+// declaration order. Slices of structs are a single setting.
+func settings(v interface{}) []setting {
+	var out []setting
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := prefix + field.Tag.Get("config")
+			if field.Type.Kind() == reflect.Struct {
+				walk(key+".", v.Field(i))
+				continue
+			}
+			out = append(out, setting{
+				key:    key,
+				env:    field.Tag.Get("env"),
+				unit:   units[field.Tag.Get("unit")],
+				secret: field.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk("", reflect.ValueOf(v).Elem())
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses raw into s. Bare numbers given for a duration are in s.unit.
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	v := s.value
+	switch {
+	case v.Type() == durationType:
+		if s.unit != 0 {
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				v.SetInt(n * int64(s.unit))
+				return nil
+			}
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			if s.unit != 0 {
+				return fmt.Errorf("must be a whole number of %s or a duration such as 1h30m, got %q", unitName(s.unit), raw)
+			}
+			return fmt.Errorf("must be a duration such as 30s or 1h30m, got %q", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be a whole number, got %q", raw)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", raw)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", raw)
+		}
+		v.SetBool(b)
+	case v.Type() == reflect.TypeOf([]string(nil)):
+		v.Set(reflect.ValueOf(strings.Fields(strings.ReplaceAll(raw, ",", " "))))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+func unitName(unit time.Duration) string {
+	switch unit {
+	case time.Second:
+		return "seconds"
+	case time.Minute:
+		return "minutes"
+	case time.Hour:
+		return "hours"
+	default:
+		return "days"
+	}
+}
+
+// loadFile applies a YAML or TOML file, picked by extension, to cfg.
+// Unknown keys are reported so typos don't go unnoticed.
+func loadFile(cfg *Config, path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []string{fmt.Sprintf("read config file: %v", err)}
+	}
+
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return []string{fmt.Sprintf("config file %s must end in .yaml, .yml or .toml", path)}
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("parse %s: %v", path, err)}
+	}
+
+	values := map[string]interface{}{}
+	flatten("", tree, values)
+
+	var problems []string
+	for _, s := range settings(cfg) {
+		raw, ok := values[s.key]
+		if !ok {
+			continue
+		}
+		delete(values, s.key)
+		if err := setFromFile(s, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s in %s: %v", s.key, path, err))
+		}
+	}
+
+	unknown := make([]string, 0, len(values))
+	for key := range values {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("unknown setting %s in %s", key, path))
+	}
+	return problems
+}
+
+// flatten turns nested tables into dotted keys.
+func flatten(prefix string, tree map[string]interface{}, out map[string]interface{}) {
+	for key, value := range tree {
+		if table, ok := value.(map[string]interface{}); ok {
+			flatten(prefix+key+".", table, out)
+			continue
+		}
+		out[prefix+key] = value
+	}
+}
+
+func setFromFile(s setting, raw interface{}) error {
+	switch s.value.Interface().(type) {
+	case []OIDCProvider:
+		return setProviders(s, raw)
+	case []string:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return s.set(fmt.Sprint(raw))
+		}
+		strs := make([]string, len(list))
+		for i, item := range list {
+			strs[i] = fmt.Sprint(item)
+		}
+		s.value.Set(reflect.ValueOf(strs))
+		return nil
+	}
+	switch raw.(type) {
+	case []interface{}, map[string]interface{}:
+		return errors.New("must be a single value")
+	}
+	return s.set(fmt.Sprint(raw))
+}
+
+func setProviders(s setting, raw interface{}) error {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return errors.New("must be a list of providers")
+	}
+	providers := make([]OIDCProvider, len(list))
+	for i, item := range list {
+		table, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("provider %d must be a table of settings", i+1)
+		}
+		for _, field := range settings(&providers[i]) {
+			value, ok := table[field.key]
+			if !ok {
+				continue
+			}
+			delete(table, field.key)
+			if err := setFromFile(field, value); err != nil {
+				return fmt.Errorf("provider %d: %s %v", i+1, field.key, err)
+			}
+		}
+		for key := range table {
+			return fmt.Errorf("provider %d: unknown setting %s", i+1, key)
+		}
+	}
+	s.value.Set(reflect.ValueOf(providers))
+	return nil
+}
+
+// lookupSecret reads name, or the file named by name_FILE.
+func lookupSecret(lookupEnv func(string) (string, bool), name string) (string, bool, error) {
+	value, _ := lookupEnv(name)
+	path, _ := lookupEnv(name + "_FILE")
+	switch {
+	case path == "":
+		return value, value != "", nil
+	case value != "":
+		return "", false, fmt.Errorf("set %s or %s_FILE, not both", name, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %v", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// loadEnv applies environment variables to cfg.
+func loadEnv(cfg *Config, lookupEnv func(string) (string, bool)) []string {
+	var problems []string
+	for _, s := range settings(cfg) {
+		if s.env == "" {
+			continue
+		}
+		if _, ok := s.value.Interface().([]OIDCProvider); ok {
+			problems = append(problems, loadProvidersEnv(s, lookupEnv)...)
+			continue
+		}
+		raw, ok, err := lookupSecret(lookupEnv, s.env)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := s.set(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", s.env, err))
+		}
+	}
+	return problems
+}
+
+// loadProvidersEnv adds the providers named in OIDC_PROVIDERS and applies
+// OIDC_<NAME>_* variables to them and to providers from the config file.
+func loadProvidersEnv(s setting, lookupEnv func(string) (string, bool)) []string {
+	providers := s.value.Interface().([]OIDCProvider)
+	names, _ := lookupEnv(s.env)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && indexOfProvider(providers, name) < 0 {
+			providers = append(providers, OIDCProvider{Name: name})
+		}
+	}
+
+	var problems []string
+	for i := range providers {
+		prefix := "OIDC_" + strings.ToUpper(providers[i].Name) + "_"
+		for _, field := range settings(&providers[i]) {
+			if field.env == "" {
+				continue
+			}
+			raw, ok, err := lookupSecret(lookupEnv, prefix+field.env)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if err := field.set(raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s %v", prefix+field.env, err))
+			}
+		}
+	}
+	s.value.Set(reflect.ValueOf(providers))
+	return problems
+}
+
+func indexOfProvider(providers []OIDCProvider, name string) int {
+	for i, p := range providers {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"instagram-backend/logging"
+	"log/slog"
+)
+
+// LogOptions returns the options for logging.New.
+func (c *Config) LogOptions() logging.Options {
+	var level slog.Level
+	level.UnmarshalText([]byte(c.Log.Level))
+	return logging.Options{Level: level, Format: c.Log.Format}
+}
+
+// SuccessSampleRate is the share of successful requests to log: all of them
+// outside production.
+func (c *Config) SuccessSampleRate() float64 {
+	if c.IsProduction() {
+		return c.Log.SuccessSampleRate
+	}
+	return 1
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in printed configurations.
+const redacted = "[REDACTED]"
+
+// Print writes c to w with secrets redacted, as YAML that Load accepts as a
+// config file or, with format "env", as environment variables.
+func (c *Config) Print(w io.Writer, format string) error {
+	switch format {
+	case "yaml":
+		root := &yaml.Node{Kind: yaml.MappingNode}
+		for _, s := range settings(c) {
+			put(root, strings.Split(s.key, "."), yamlValue(s))
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(root); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "env":
+		for _, line := range envLines(c) {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, want yaml or env", format)
+	}
+}
+
+// put adds value under the nested keys of path in mapping.
+func put(mapping *yaml.Node, path []string, value *yaml.Node) {
+	key := &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}
+	if len(path) == 1 {
+		mapping.Content = append(mapping.Content, key, value)
+		return
+	}
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == path[0] {
+			put(mapping.Content[i+1], path[1:], value)
+			return
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, key, child)
+	put(child, path[1:], value)
+}
+
+func yamlValue(s setting) *yaml.Node {
+	switch v := s.value.Interface().(type) {
+	case []OIDCProvider:
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := range v {
+			provider := &yaml.Node{Kind: yaml.MappingNode}
+			for _, field := range settings(&v[i]) {
+				put(provider, []string{field.key}, yamlValue(field))
+			}
+			seq.Content = append(seq.Content, provider)
+		}
+		return seq
+	case []string:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range v {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item})
+		}
+		return seq
+	}
+
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: format(s)}
+	if s.value.Kind() == reflect.String || s.value.Type() == durationType {
+		node.Tag = "!!str"
+	}
+	return node
+}
+
+// format renders a scalar setting the way Load parses it.
+func format(s setting) string {
+	if s.secret && !s.value.IsZero() {
+		return redacted
+	}
+	switch v := s.value.Interface().(type) {
+	case time.Duration:
+		return formatDuration(v)
+	case []string:
+		return strings.Join(v, ",")
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatDuration drops the zero minutes and seconds time.Duration prints,
+// e.g. 720h rather than 720h0m0s.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+func envLines(c *Config) []string {
+	var lines []string
+	for _, s := range settings(c) {
+		if s.env == "" {
+			continue
+		}
+		providers, ok := s.value.Interface().([]OIDCProvider)
+		if !ok {
+			lines = append(lines, s.env+"="+format(s))
+			continue
+		}
+
+		names := make([]string, len(providers))
+		for i, p := range providers {
+			names[i] = p.Name
+		}
+		lines = append(lines, s.env+"="+strings.Join(names, ","))
+		for i := range providers {
+			prefix := "OIDC_" + strings.ToUpper(providers[i].Name) + "_"
+			for _, field := range settings(&providers[i]) {
+				if field.env != "" {
+					lines = append(lines, prefix+field.env+"="+format(field))
+				}
+			}
+		}
+	}
+	return lines
+}
@@ -1,47 +1,44 @@
 package config
 
 import (
-	"log"
-	"os"
-	"time"
+	"instagram-backend/metrics"
+	"instagram-backend/middleware"
+	"instagram-backend/tracing"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // setupServer configures and returns a Gin engine with optimized settings
-func SetupServer() *gin.Engine {
+func SetupServer(cfg *Config, logger *slog.Logger, m *metrics.Metrics, tp trace.TracerProvider) *gin.Engine {
 	// Set Gin to release mode in production
-	if os.Getenv("ENVIRONMENT") != "development" {
+	if !cfg.IsDevelopment() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	// Create a new Gin engine with custom configuration
 	r := gin.New()
 
-	// Use custom recovery middleware
-	r.Use(gin.Recovery())
-
-	// Add custom logging middleware for production
-	r.Use(func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
-
-		c.Next()
-
-		if raw != "" {
-			path = path + "?" + raw
-		}
-
-		latency := time.Since(start)
-		if os.Getenv("ENVIRONMENT") == "production" {
-			if c.Writer.Status() >= 400 {
-				log.Printf("[ERROR] %s %s %d %s", c.Request.Method, path, c.Writer.Status(), latency)
-			}
-		} else {
-			log.Printf("[INFO] %s %s %d %s", c.Request.Method, path, c.Writer.Status(), latency)
-		}
-	})
+	// Trace every request, continuing the caller's trace if it sent a
+	// traceparent header
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName,
+		otelgin.WithTracerProvider(tp),
+		otelgin.WithPropagators(tracing.Propagator()),
+	))
+
+	// Tag every request with an ID and log it, in production sampling the
+	// successful ones
+	r.Use(middleware.RequestID(logger))
+	r.Use(middleware.AccessLog(cfg.SuccessSampleRate()))
+
+	// Time every request by route for /metrics. This goes before Recovery so
+	// requests that panic are counted with the 500 Recovery responds with.
+	r.Use(middleware.Metrics(m))
+
+	// Log panics with the request's logger
+	r.Use(middleware.Recovery())
 
 	return r
 }
@@ -0,0 +1,14 @@
+package config
+
+import "instagram-backend/tracing"
+
+// TracingOptions returns the options for tracing.NewProvider.
+func (c *Config) TracingOptions() tracing.Options {
+	return tracing.Options{
+		Exporter:    c.Tracing.Exporter,
+		Endpoint:    c.Tracing.Endpoint,
+		ServiceName: c.Tracing.ServiceName,
+		Environment: c.Environment,
+		SampleRatio: c.Tracing.SampleRatio,
+	}
+}
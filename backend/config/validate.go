@@ -0,0 +1,147 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// validator collects problems, naming each setting by its file key and
+// environment variable.
+type validator struct {
+	env      map[string]string
+	problems []string
+}
+
+func (v *validator) check(ok bool, key, format string, args ...interface{}) {
+	if ok {
+		return
+	}
+	name := key
+	if env := v.env[key]; env != "" {
+		name += " (" + env + ")"
+	}
+	v.problems = append(v.problems, name+" "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) positive(d time.Duration, key string) {
+	v.check(d > 0, key, "must be positive, got %s", d)
+}
+
+func (v *validator) oneOf(value, key string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.check(false, key, "must be one of %q, got %q", allowed, value)
+}
+
+func (v *validator) absoluteURL(value, key string) {
+	u, err := url.Parse(value)
+	v.check(err == nil && u.Scheme != "" && u.Host != "", key, "must be an absolute URL, got %q", value)
+}
+
+// validate lists every problem with c.
+func (c *Config) validate() []string {
+	v := &validator{env: map[string]string{}}
+	for _, s := range settings(c) {
+		v.env[s.key] = s.env
+	}
+
+	v.oneOf(c.Environment, "environment", "development", "staging", "production")
+
+	v.oneOf(c.Log.Level, "log.level", "debug", "info", "warn", "error")
+	v.oneOf(c.Log.Format, "log.format", "json", "text")
+	v.check(c.Log.SuccessSampleRate >= 0 && c.Log.SuccessSampleRate <= 1, "log.success_sample_rate",
+		"must be between 0 and 1, got %g", c.Log.SuccessSampleRate)
+
+	v.oneOf(c.Tracing.Exporter, "tracing.exporter", "none", "otlp")
+	if c.Tracing.Endpoint != "" {
+		v.absoluteURL(c.Tracing.Endpoint, "tracing.endpoint")
+	}
+	v.check(c.Tracing.ServiceName != "", "tracing.service_name", "is required")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio",
+		"must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	port, err := strconv.Atoi(c.Server.Port)
+	v.check(err == nil && port > 0 && port < 65536, "server.port", "must be a port number, got %q", c.Server.Port)
+	v.absoluteURL(c.Server.BaseURL, "server.base_url")
+	v.check(c.Server.ReadTimeout >= 0, "server.read_timeout", "must not be negative")
+	v.check(c.Server.WriteTimeout >= 0, "server.write_timeout", "must not be negative")
+	v.check(c.Server.IdleTimeout >= 0, "server.idle_timeout", "must not be negative")
+	v.check(c.Server.RequestsPerSecond > 0, "server.requests_per_second", "must be positive, got %d", c.Server.RequestsPerSecond)
+
+	v.check(c.Database.URL != "", "database.url", "is required")
+	v.check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative")
+	v.check(c.Database.MaxOpenConns >= 0, "database.max_open_conns", "must not be negative")
+	v.check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "must not be negative")
+	v.check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time", "must not be negative")
+
+	v.check(c.Redis.Addr != "", "redis.addr", "is required")
+	v.check(c.Redis.PoolSize >= 0, "redis.pool_size", "must not be negative")
+	v.check(c.Redis.MinIdleConns >= 0, "redis.min_idle_conns", "must not be negative")
+	v.check(c.Redis.MaxRetries >= 0, "redis.max_retries", "must not be negative")
+	v.check(c.Redis.DialTimeout >= 0, "redis.dial_timeout", "must not be negative")
+	v.check(c.Redis.ReadTimeout >= 0, "redis.read_timeout", "must not be negative")
+	v.check(c.Redis.WriteTimeout >= 0, "redis.write_timeout", "must not be negative")
+	v.check(c.Redis.PoolTimeout >= 0, "redis.pool_timeout", "must not be negative")
+
+	v.positive(c.Cache.Expiration, "cache.expiration")
+
+	v.oneOf(c.JWT.SigningAlg, "jwt.signing_alg", "EdDSA", "RS256")
+	v.positive(c.JWT.KeyRotationInterval, "jwt.key_rotation_interval")
+	v.positive(c.JWT.KeyPrePublish, "jwt.key_prepublish")
+	v.positive(c.JWT.TokenLifetime, "jwt.token_lifetime")
+	v.check(c.JWT.KeyOverlap > c.JWT.TokenLifetime, "jwt.key_overlap",
+		"must be longer than the token lifetime, %s, got %s", c.JWT.TokenLifetime, c.JWT.KeyOverlap)
+	if c.JWT.KeyEncryptionKey == "" {
+		v.check(c.IsDevelopment(), "jwt.key_encryption_key", "is required outside development")
+	} else {
+		key, err := base64.StdEncoding.DecodeString(c.JWT.KeyEncryptionKey)
+		v.check(err == nil && len(key) == 32, "jwt.key_encryption_key", "must be 32 bytes, base64 encoded")
+	}
+
+	v.positive(c.Auth.EmailVerificationTTL, "auth.email_verification_ttl")
+	v.positive(c.Auth.VerificationResendCooldown, "auth.verification_resend_cooldown")
+	v.positive(c.Auth.UsernameChangeCooldown, "auth.username_change_cooldown")
+	v.positive(c.Auth.AccountDeletionGrace, "auth.account_deletion_grace")
+	v.positive(c.Auth.ImpersonationTTL, "auth.impersonation_ttl")
+	v.check(c.Auth.TOTPIssuer != "", "auth.totp_issuer", "is required")
+
+	v.oneOf(c.Mail.Driver, "mail.driver", "log", "smtp")
+	if c.Mail.Driver == "smtp" {
+		v.check(c.Mail.SMTPHost != "", "mail.smtp_host", "is required when mail.driver is smtp")
+		port, err := strconv.Atoi(c.Mail.SMTPPort)
+		v.check(err == nil && port > 0 && port < 65536, "mail.smtp_port", "must be a port number, got %q", c.Mail.SMTPPort)
+		v.check(c.Mail.From != "", "mail.from", "is required when mail.driver is smtp")
+	}
+
+	seen := map[string]bool{}
+	for i, p := range c.OIDC.Providers {
+		key := fmt.Sprintf("oidc.providers[%d]", i)
+		v.check(p.Name != "", key, "needs a name")
+		v.check(!seen[p.Name], key, "repeats provider %q", p.Name)
+		seen[p.Name] = true
+		v.absoluteURL(p.Issuer, key+".issuer")
+		v.check(p.ClientID != "", key+".client_id", "is required")
+		v.absoluteURL(p.RedirectURL, key+".redirect_url")
+	}
+
+	v.check(c.ContentFilter.DefaultLocale != "", "content_filter.default_locale", "is required")
+	v.check(c.ContentFilter.MaxLinks >= 0, "content_filter.max_links", "must not be negative")
+	v.check(c.ContentFilter.MaxRepeats > 0, "content_filter.max_repeats", "must be positive, got %d", c.ContentFilter.MaxRepeats)
+	v.positive(c.ContentFilter.DuplicateWindow, "content_filter.duplicate_window")
+
+	v.check(c.Moderation.ReportAutoHideThreshold > 0, "moderation.report_auto_hide_threshold",
+		"must be positive, got %d", c.Moderation.ReportAutoHideThreshold)
+
+	v.positive(c.Retention.Trash, "retention.trash")
+	v.positive(c.Retention.Audit, "retention.audit")
+	v.check(c.Retention.ExportDir != "", "retention.export_dir", "is required")
+	v.positive(c.Retention.Export, "retention.export")
+
+	return v.problems
+}
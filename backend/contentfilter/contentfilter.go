@@ -0,0 +1,125 @@
+// Package contentfilter screens user-written text such as comments and post
+// captions before it is saved. Filters are chained; the strictest verdict wins.
+package contentfilter
+
+import (
+	"context"
+	"strings"
+)
+
+// Action is what should happen to a piece of text.
+type Action int
+
+const (
+	// Allow publishes the text as-is.
+	Allow Action = iota
+	// Hold saves the text but keeps it out of sight until someone reviews it.
+	Hold
+	// Reject refuses to save the text.
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// Kinds of text that can be checked.
+const (
+	KindComment = "comment"
+	KindCaption = "caption"
+)
+
+// Input is the text under review and who it is from and for.
+type Input struct {
+	Kind   string
+	Text   string
+	Locale string // BCP 47 language tag, e.g. "en" or "pt-BR"
+	// AuthorID wrote the text; SellerID owns the post it belongs to.
+	AuthorID uint
+	SellerID uint
+}
+
+// Verdict is a filter's decision. Reason is shown to moderators and, for
+// rejections, to the author.
+type Verdict struct {
+	Action Action
+	Filter string
+	Reason string
+}
+
+// Filter inspects text and returns a verdict.
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, in *Input) (Verdict, error)
+}
+
+// Chain runs filters in order and returns the strictest verdict. It stops at
+// the first rejection.
+type Chain []Filter
+
+func (ch Chain) Name() string { return "chain" }
+
+func (ch Chain) Check(ctx context.Context, in *Input) (Verdict, error) {
+	result := Verdict{Action: Allow}
+	for _, f := range ch {
+		v, err := f.Check(ctx, in)
+		if err != nil {
+			return Verdict{}, err
+		}
+		if v.Action > result.Action {
+			result = v
+			if result.Filter == "" {
+				result.Filter = f.Name()
+			}
+		}
+		if result.Action == Reject {
+			break
+		}
+	}
+	return result, nil
+}
+
+// violation is the verdict for text a filter objects to: comments are held
+// for review, captions are rejected so the author can fix them.
+func violation(in *Input, filter, reason string) Verdict {
+	action := Hold
+	if in.Kind == KindCaption {
+		action = Reject
+	}
+	return Verdict{Action: action, Filter: filter, Reason: reason}
+}
+
+// normalize lowercases text and undoes common letter substitutions so
+// "Fr33 M0ney" matches "free money".
+func normalize(text string) string {
+	return leetReplacer.Replace(strings.ToLower(text))
+}
+
+var leetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+
+// containsPhrase reports whether phrase appears in normalized text on word
+// boundaries.
+func containsPhrase(text, phrase string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], phrase)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(phrase)
+		if (start == 0 || !isWordByte(text[start-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '_' || b >= 0x80
+}
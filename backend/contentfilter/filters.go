@@ -0,0 +1,160 @@
+package contentfilter
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//go:embed wordlists/*.txt
+var defaultWordLists embed.FS
+
+// WordList holds or rejects text containing words from a per-locale list.
+// Locales fall back from "pt-BR" to "pt"; the Default locale's list always
+// applies as well.
+type WordList struct {
+	Default string
+	Words   map[string][]string
+}
+
+// LoadWordLists reads every <locale>.txt file in dir, one word or phrase per
+// line with # comments. An empty dir loads the built-in lists.
+func LoadWordLists(dir, defaultLocale string) (*WordList, error) {
+	var fsys fs.FS = os.DirFS(dir)
+	pattern := "*.txt"
+	if dir == "" {
+		fsys, pattern = defaultWordLists, "wordlists/*.txt"
+	}
+
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	wl := &WordList{Default: strings.ToLower(defaultLocale), Words: make(map[string][]string)}
+	for _, name := range files {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		locale := strings.ToLower(strings.TrimSuffix(filepath.Base(name), ".txt"))
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			wl.Words[locale] = append(wl.Words[locale], normalize(line))
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+	}
+	return wl, nil
+}
+
+func (wl *WordList) Name() string { return "wordlist" }
+
+func (wl *WordList) Check(ctx context.Context, in *Input) (Verdict, error) {
+	text := normalize(in.Text)
+	for _, locale := range wl.locales(in.Locale) {
+		for _, word := range wl.Words[locale] {
+			if containsPhrase(text, word) {
+				return violation(in, wl.Name(), "contains blocked language"), nil
+			}
+		}
+	}
+	return Verdict{Action: Allow}, nil
+}
+
+func (wl *WordList) locales(tag string) []string {
+	tag = strings.ToLower(tag)
+	locales := []string{wl.Default}
+	if tag != "" && tag != wl.Default {
+		locales = append(locales, tag)
+	}
+	if base, _, ok := strings.Cut(tag, "-"); ok && base != wl.Default {
+		locales = append(locales, base)
+	}
+	return locales
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:com|net|org|io|ly|me|shop|store|xyz|top|link|click)\b`)
+
+// LinkFilter holds comments with more than MaxLinks links. Captions are not
+// checked since sellers link their products there.
+type LinkFilter struct {
+	MaxLinks int
+}
+
+func (f LinkFilter) Name() string { return "links" }
+
+func (f LinkFilter) Check(ctx context.Context, in *Input) (Verdict, error) {
+	if in.Kind != KindComment {
+		return Verdict{Action: Allow}, nil
+	}
+	if n := len(linkPattern.FindAllStringIndex(in.Text, -1)); n > f.MaxLinks {
+		return violation(in, f.Name(), fmt.Sprintf("contains %d links", n)), nil
+	}
+	return Verdict{Action: Allow}, nil
+}
+
+// DuplicateFilter holds a comment when its author already posted the same
+// text MaxRepeats times within Window, a telltale of spam runs across posts.
+type DuplicateFilter struct {
+	MaxRepeats int
+	Window     time.Duration
+	// CountRecent counts the author's comments with exactly this text since
+	// the given time.
+	CountRecent func(ctx context.Context, authorID uint, text string, since time.Time) (int64, error)
+}
+
+func (f DuplicateFilter) Name() string { return "duplicates" }
+
+func (f DuplicateFilter) Check(ctx context.Context, in *Input) (Verdict, error) {
+	if in.Kind != KindComment || f.CountRecent == nil {
+		return Verdict{Action: Allow}, nil
+	}
+	n, err := f.CountRecent(ctx, in.AuthorID, in.Text, time.Now().Add(-f.Window))
+	if err != nil {
+		return Verdict{}, err
+	}
+	if n >= int64(f.MaxRepeats) {
+		return violation(in, f.Name(), "repeats a recent message"), nil
+	}
+	return Verdict{Action: Allow}, nil
+}
+
+// KeywordFilter applies the keywords a seller has blocked on their own posts.
+type KeywordFilter struct {
+	// Keywords returns the seller's blocked keywords.
+	Keywords func(ctx context.Context, sellerID uint) ([]string, error)
+}
+
+func (f KeywordFilter) Name() string { return "seller_keywords" }
+
+func (f KeywordFilter) Check(ctx context.Context, in *Input) (Verdict, error) {
+	// Sellers' own words are never filtered by their own list
+	if in.Kind != KindComment || in.SellerID == 0 || in.SellerID == in.AuthorID || f.Keywords == nil {
+		return Verdict{Action: Allow}, nil
+	}
+	keywords, err := f.Keywords(ctx, in.SellerID)
+	if err != nil {
+		return Verdict{}, err
+	}
+	text := normalize(in.Text)
+	for _, keyword := range keywords {
+		if containsPhrase(text, normalize(keyword)) {
+			return violation(in, f.Name(), "contains a keyword the seller has blocked"), nil
+		}
+	}
+	return Verdict{Action: Allow}, nil
+}
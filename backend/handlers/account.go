@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type DeleteAccountRequest struct {
+	ConfirmUsername string `json:"confirmUsername" binding:"required"`
+	// Password is required unless the account signs in through a social login.
+	Password string `json:"password"`
+}
+
+// @Summary Delete account
+// @Description Schedule the current account for permanent deletion after a grace period and sign out everywhere
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param confirmation body DeleteAccountRequest true "Username confirmation and password"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me [delete]
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.repos.Users.FindByID(ctx, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if req.ConfirmUsername != user.Username {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username confirmation does not match"})
+		return
+	}
+
+	if req.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			return
+		}
+	} else {
+		if linked, _ := h.repos.Identities.Linked(ctx, user.ID); !linked {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password is required"})
+			return
+		}
+	}
+
+	scheduledAt := time.Now().Add(h.cfg.Auth.AccountDeletionGrace)
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		user.DeletionScheduledAt = &scheduledAt
+		if err := tx.Users.Update(ctx, user, "DeletionScheduledAt"); err != nil {
+			return err
+		}
+		if _, err := tx.Sessions.RevokeAll(ctx, user.ID, 0); err != nil {
+			return err
+		}
+		if err := tx.APIKeys.RevokeAll(ctx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionUserDeleteRequest,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]interface{}{"deletionScheduledAt": scheduledAt},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+
+	if err := h.cache.InvalidateUserCache(c.Request.Context(), user.ID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", user.ID, "error", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":             "Account scheduled for deletion. To keep it, sign in and call POST /api/v1/me/deletion/cancel before the deadline.",
+		"deletionScheduledAt": scheduledAt,
+	})
+}
+
+// @Summary Cancel account deletion
+// @Description Keep the current account after a deletion request
+// @Tags account
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/deletion/cancel [post]
+func (h *AuthHandler) CancelAccountDeletion(c *gin.Context) {
+	cancelled, err := h.repos.Users.CancelDeletion(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+
+	if !cancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is not scheduled for deletion"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{Action: audit.ActionUserDeleteCancel, TargetType: audit.TargetUser, TargetID: c.GetUint("user_id")})
+
+	if err := h.cache.InvalidateUserCache(c.Request.Context(), c.GetUint("user_id")); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", c.GetUint("user_id"), "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
+
+// @Summary Request data export
+// @Description Start building an archive of the current user's profile, posts, comments and likes
+// @Tags account
+// @Produce json
+// @Success 202 {object} models.DataExport
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/exports [post]
+func (h *AuthHandler) RequestDataExport(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	if pending, _ := h.repos.DataExports.HasPending(c.Request.Context(), userID); pending {
+		c.JSON(http.StatusConflict, gin.H{"error": "An export is already being prepared"})
+		return
+	}
+
+	export := models.DataExport{UserID: userID, Status: "pending"}
+	if err := h.repos.DataExports.Create(c.Request.Context(), &export); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+		return
+	}
+
+	// Build the archive in the background; the client polls GET /me/exports.
+	go h.buildExport(context.WithoutCancel(c.Request.Context()), export.ID)
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+// @Summary List data exports
+// @Description List the current user's data exports and their status
+// @Tags account
+// @Produce json
+// @Success 200 {array} models.DataExport
+// @Security BearerAuth
+// @Router /api/v1/me/exports [get]
+func (h *AuthHandler) ListDataExports(c *gin.Context) {
+	exports, err := h.repos.DataExports.List(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exports"})
+		return
+	}
+	c.JSON(http.StatusOK, exports)
+}
+
+// @Summary Download data export
+// @Description Download a finished export archive
+// @Tags account
+// @Produce application/zip
+// @Param id path int true "Export ID"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/exports/{id}/download [get]
+func (h *AuthHandler) DownloadDataExport(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+	export, err := h.repos.DataExports.Find(c.Request.Context(), id, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+
+	if export.Status != "ready" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is not ready"})
+		return
+	}
+
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export has expired"})
+		return
+	}
+
+	c.FileAttachment(export.FilePath, fmt.Sprintf("instagram-data-%d.zip", export.ID))
+}
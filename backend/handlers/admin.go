@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"errors"
+	"instagram-backend/audit"
+	"instagram-backend/config"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves the admin API. Every change it makes is written to the
+// audit log in the same transaction.
+type AdminHandler struct {
+	repos *repository.Repositories
+	auth  *AuthHandler
+	// impersonationTTL is how long a support session opened by an admin lasts.
+	impersonationTTL time.Duration
+}
+
+func NewAdminHandler(cfg *config.Config, repos *repository.Repositories, auth *AuthHandler) *AdminHandler {
+	return &AdminHandler{repos: repos, auth: auth, impersonationTTL: cfg.Auth.ImpersonationTTL}
+}
+
+type AdminSuspendRequest struct {
+	Days   int    `json:"days" binding:"required,min=1,max=3650"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type AdminRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=buyer seller admin"`
+	// Username replaces the user's username when it doesn't fit the new
+	// role, e.g. promoting a buyer to seller needs a category/handle name.
+	Username string `json:"username"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// adminTarget loads the user named by the :id parameter.
+func (h *AdminHandler) adminTarget(c *gin.Context) (*models.User, bool) {
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	user, err := h.repos.Users.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+// @Summary Search users
+// @Description Search users by username, email or name
+// @Tags admin
+// @Produce json
+// @Param q query string false "Text to search for"
+// @Param role query string false "Only users with this role"
+// @Param suspended query bool false "Only currently suspended users"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {array} models.User
+// @Security BearerAuth
+// @Router /api/v1/admin/users [get]
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	filter := repository.UserFilter{
+		Query:     strings.TrimSpace(c.Query("q")),
+		Role:      c.Query("role"),
+		Suspended: c.Query("suspended") == "true",
+	}
+	users, err := h.repos.Users.Search(c.Request.Context(), filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// @Summary Suspend user
+// @Description Suspend a user for a number of days. They can still sign in to read notices and appeal.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param suspension body AdminSuspendRequest true "Suspension"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/suspend [post]
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	var req AdminSuspendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+	adminID := c.GetUint("user_id")
+	if user.ID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot suspend yourself"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	until := time.Now().Add(time.Duration(req.Days) * 24 * time.Hour)
+	user.SuspendedUntil = &until
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Users.Suspend(ctx, user.ID, &until); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionUserSuspend,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]interface{}{"until": until, "reason": req.Reason},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+
+	if err := h.auth.cache.InvalidateUserCache(ctx, user.ID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", user.ID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Unsuspend user
+// @Description Lift a user's suspension
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/suspend [delete]
+func (h *AdminHandler) UnsuspendUser(c *gin.Context) {
+	user, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Users.Suspend(ctx, user.ID, nil); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{Action: audit.ActionUserUnsuspend, TargetType: audit.TargetUser, TargetID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend user"})
+		return
+	}
+
+	if err := h.auth.cache.InvalidateUserCache(ctx, user.ID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", user.ID, "error", err)
+	}
+
+	user.SuspendedUntil = nil
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Force-verify email
+// @Description Mark a user's email as verified without the emailed link, e.g. for sellers verified by support
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/verify-email [post]
+func (h *AdminHandler) VerifyUserEmail(c *gin.Context) {
+	user, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Users.Update(ctx, user, "EmailVerified", "EmailVerifiedAt"); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{Action: audit.ActionUserVerifyEmail, TargetType: audit.TargetUser, TargetID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	if err := h.auth.cache.InvalidateUserCache(ctx, user.ID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", user.ID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Change role
+// @Description Change a user's role. Promoting to seller needs a category/handle username, passed as username if the current one doesn't fit.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body AdminRoleRequest true "New role"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/role [put]
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	var req AdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+	adminID := c.GetUint("user_id")
+	if user.ID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	username := user.Username
+	if req.Username != "" {
+		username = req.Username
+	}
+	// Admins are named like buyers
+	nameRole := req.Role
+	if nameRole == "admin" {
+		nameRole = "buyer"
+	}
+	ctx := c.Request.Context()
+	username, category, err := validateUsername(ctx, h.repos.Categories, username, nameRole)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	changes := map[string]audit.Change{"role": {From: user.Role, To: req.Role}}
+	oldUsername := user.Username
+	renamed := username != oldUsername
+	user.Role = req.Role
+	user.Username = username
+	user.CategoryID = nil
+	if category != nil {
+		user.CategoryID = &category.ID
+	}
+
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if renamed {
+			available, err := tx.Users.UsernameAvailable(ctx, username, user.ID)
+			if err != nil {
+				return err
+			}
+			if !available {
+				return errUsernameTaken
+			}
+			if err := tx.Users.Rename(ctx, user.ID, oldUsername, username); err != nil {
+				return err
+			}
+			changes["username"] = audit.Change{From: oldUsername, To: username}
+		}
+		if err := tx.Users.Update(ctx, user, "Role", "Username", "CategoryID"); err != nil {
+			return err
+		}
+		if renamed || req.Role != "seller" {
+			if err := revokeVerification(tx, c, user.ID, "username or role changed"); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionUserChangeRole,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Changes:    changes,
+		})
+	})
+	if errors.Is(err, errUsernameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+
+	invalidateAuthorCaches(c, h.repos.Posts, h.auth.cache, user.ID)
+
+	if updated, err := h.repos.Users.FindByID(ctx, user.ID); err == nil {
+		user = updated
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Impersonate user
+// @Description Open a short-lived support session acting as a user. Admin accounts cannot be impersonated.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param impersonation body ImpersonateRequest true "Why support needs access"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (h *AdminHandler) ImpersonateUser(c *gin.Context) {
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.adminTarget(c)
+	if !ok {
+		return
+	}
+	if user.Role == "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin accounts cannot be impersonated"})
+		return
+	}
+
+	adminID := c.GetUint("user_id")
+	now := time.Now()
+	session := models.Session{
+		UserID:         user.ID,
+		DeviceName:     "Support session",
+		UserAgent:      c.Request.UserAgent(),
+		IPAddress:      c.ClientIP(),
+		LastSeenAt:     now,
+		ExpiresAt:      now.Add(h.impersonationTTL),
+		ImpersonatorID: &adminID,
+	}
+	ctx := c.Request.Context()
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Sessions.Create(ctx, &session); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionUserImpersonate,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]interface{}{"sessionId": session.ID, "reason": req.Reason},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	token, err := h.auth.generateToken(&session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":     token,
+		"expiresAt": session.ExpiresAt,
+		"user":      user,
+	})
+}
+
+// setDeleted soft-deletes or restores a post or comment and records it. Posts
+// take their images, purchase options, likes and comments with them.
+func (h *AdminHandler) setDeleted(c *gin.Context, targetType string, deleted bool, action string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	adminID := c.GetUint("user_id")
+	var result interface{}
+	var postID uint
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		switch targetType {
+		case audit.TargetPost:
+			post, err := tx.Posts.FindWithDeleted(ctx, uint(id))
+			if err != nil {
+				return err
+			}
+			if deleted {
+				err = tx.Posts.Trash(ctx, post.ID, adminID)
+			} else {
+				err = tx.Posts.Restore(ctx, post.ID)
+			}
+			if err != nil {
+				return err
+			}
+			if result, err = tx.Posts.FindWithDeleted(ctx, post.ID); err != nil {
+				return err
+			}
+			postID = post.ID
+		case audit.TargetComment:
+			comment, err := tx.Comments.FindWithDeleted(ctx, uint(id))
+			if err != nil {
+				return err
+			}
+			if deleted {
+				err = tx.Comments.Trash(ctx, comment.ID, adminID)
+			} else {
+				err = tx.Comments.Restore(ctx, comment)
+			}
+			if err != nil {
+				return err
+			}
+			if result, err = tx.Comments.FindWithDeleted(ctx, comment.ID); err != nil {
+				return err
+			}
+			// The post embeds its comments in the cache
+			postID = comment.PostID
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{Action: action, TargetType: targetType, TargetID: uint(id)})
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrParentPostDeleted) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update " + targetType})
+		return
+	}
+
+	if err := h.auth.cache.InvalidatePostCache(ctx, postID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate post cache", "post_id", postID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Delete post
+// @Description Soft-delete any post. It can be restored later.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} models.Post
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/posts/{id} [delete]
+func (h *AdminHandler) DeletePost(c *gin.Context) {
+	h.setDeleted(c, audit.TargetPost, true, audit.ActionPostDelete)
+}
+
+// @Summary Restore post
+// @Description Restore a soft-deleted post
+// @Tags admin
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} models.Post
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/posts/{id}/restore [post]
+func (h *AdminHandler) RestorePost(c *gin.Context) {
+	h.setDeleted(c, audit.TargetPost, false, audit.ActionPostRestore)
+}
+
+// @Summary Delete comment
+// @Description Soft-delete any comment. It can be restored later.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} models.Comment
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/comments/{id} [delete]
+func (h *AdminHandler) DeleteComment(c *gin.Context) {
+	h.setDeleted(c, audit.TargetComment, true, audit.ActionCommentDelete)
+}
+
+// @Summary Restore comment
+// @Description Restore a soft-deleted comment. Its post must not be deleted.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} models.Comment
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/comments/{id}/restore [post]
+func (h *AdminHandler) RestoreComment(c *gin.Context) {
+	h.setDeleted(c, audit.TargetComment, false, audit.ActionCommentRestore)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"instagram-backend/audit"
+	"instagram-backend/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit writes an audit entry through audits. Pass a transaction's
+// repository and return the error so the entry is only kept if the change is.
+// Failures are also logged, so callers recording an action that has already
+// happened can ignore the error rather than fail the request.
+func recordAudit(audits repository.AuditRepository, c *gin.Context, e audit.Entry) error {
+	event, err := audit.Event(c, e)
+	if err == nil {
+		err = audits.Record(c.Request.Context(), event)
+	}
+	if err != nil {
+		requestLogger(c).Error("Failed to record audit event", "action", e.Action, "error", err)
+	}
+	return err
+}
+
+// @Summary Query audit log
+// @Description List audit events, newest first. Filters can be combined.
+// @Tags admin
+// @Produce json
+// @Param actorId query int false "Who acted"
+// @Param action query string false "Action, e.g. post.delete"
+// @Param targetType query string false "Target type, e.g. user or post"
+// @Param targetId query int false "Target ID, used with targetType"
+// @Param requestId query string false "Request ID"
+// @Param since query string false "RFC 3339 time to start from"
+// @Param until query string false "RFC 3339 time to end at"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {array} models.AuditEvent
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/audit [get]
+func (h *AdminHandler) ListAuditEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	filter := repository.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		RequestID:  c.Query("requestId"),
+	}
+	for param, id := range map[string]**uint{"actorId": &filter.ActorID, "targetId": &filter.TargetID} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return
+		}
+		parsed := uint(n)
+		*id = &parsed
+	}
+	for param, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " time, expected RFC 3339"})
+			return
+		}
+		*t = parsed
+	}
+
+	events, err := h.repos.Audit.List(c.Request.Context(), filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit events"})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
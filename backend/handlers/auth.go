@@ -1,25 +1,44 @@
 package handlers
 
 import (
-	"os"
-	"strconv"
+	"context"
+	"instagram-backend/cache"
+	"instagram-backend/config"
+	"instagram-backend/keys"
+	"instagram-backend/mailer"
+	"instagram-backend/metrics"
+	"instagram-backend/repository"
+	"instagram-backend/service"
 	"time"
-
-	"gorm.io/gorm"
 )
 
+// ExportBuilder builds a requested data export in the background.
+type ExportBuilder func(ctx context.Context, exportID uint)
+
 type AuthHandler struct {
-	db        *gorm.DB
-	rateLimit *time.Ticker
+	cfg           *config.Config
+	repos         *repository.Repositories
+	users         *service.UserService
+	subscriptions *service.SubscriptionService
+	cache         *cache.Cache
+	metrics       *metrics.Metrics
+	rateLimit     *time.Ticker
+	mailer        mailer.Mailer
+	keys          *keys.KeySet
+	buildExport   ExportBuilder
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	requestsPerSecond, _ := strconv.Atoi(os.Getenv("RATE_LIMIT_REQUESTS_PER_SECOND"))
-	if requestsPerSecond <= 0 {
-		requestsPerSecond = 10 // Default value
-	}
+func NewAuthHandler(cfg *config.Config, repos *repository.Repositories, services *service.Services, cache *cache.Cache, m mailer.Mailer, ks *keys.KeySet, metrics *metrics.Metrics, buildExport ExportBuilder) *AuthHandler {
 	return &AuthHandler{
-		db:        db,
-		rateLimit: time.NewTicker(time.Second / time.Duration(requestsPerSecond)),
+		cfg:           cfg,
+		repos:         repos,
+		users:         services.Users,
+		subscriptions: services.Subscriptions,
+		cache:         cache,
+		metrics:       metrics,
+		rateLimit:     time.NewTicker(time.Second / time.Duration(cfg.Server.RequestsPerSecond)),
+		mailer:        m,
+		keys:          ks,
+		buildExport:   buildExport,
 	}
 }
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"instagram-backend/audit"
+	"instagram-backend/middleware"
+	"instagram-backend/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiKeyTokenPrefix = "igk_"
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays,omitempty" binding:"omitempty,min=1"`
+}
+
+func apiKeyResponse(key models.APIKey) gin.H {
+	return gin.H{
+		"id":         key.ID,
+		"name":       key.Name,
+		"prefix":     key.Prefix,
+		"scopes":     strings.Fields(key.Scopes),
+		"createdAt":  key.CreatedAt,
+		"lastUsedAt": key.LastUsedAt,
+		"lastUsedIp": key.LastUsedIP,
+		"expiresAt":  key.ExpiresAt,
+		"revokedAt":  key.RevokedAt,
+	}
+}
+
+func validScope(scope string) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// @Summary Create API key
+// @Description Create a scoped API key for a seller integration. The key is only shown once.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body CreateAPIKeyRequest true "Key name, scopes and optional expiry"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/api-keys [post]
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.repos.Users.FindByID(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.Role != "seller" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only sellers can create API keys"})
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !validScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope})
+			return
+		}
+	}
+
+	prefix := apiKeyTokenPrefix + randomToken(4)
+	plaintext := prefix + "_" + randomToken(24)
+
+	key := models.APIKey{
+		UserID:  user.ID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: middleware.HashAPIKey(plaintext),
+		Scopes:  strings.Join(req.Scopes, " "),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := h.repos.APIKeys.Create(c.Request.Context(), &key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{
+		Action:     audit.ActionAPIKeyCreate,
+		TargetType: audit.TargetAPIKey,
+		TargetID:   key.ID,
+		Details:    map[string]interface{}{"name": key.Name, "scopes": req.Scopes},
+	})
+
+	response := apiKeyResponse(key)
+	response["key"] = plaintext
+	c.JSON(http.StatusCreated, response)
+}
+
+// @Summary List API keys
+// @Description List the current user's API keys, including revoked ones
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/v1/me/api-keys [get]
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.repos.APIKeys.List(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Revoke API key
+// @Description Revoke one of the current user's API keys
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/api-keys/{id} [delete]
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	keyID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	revoked, err := h.repos.APIKeys.Revoke(c.Request.Context(), keyID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{Action: audit.ActionAPIKeyRevoke, TargetType: audit.TargetAPIKey, TargetID: keyID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
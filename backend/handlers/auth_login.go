@@ -1,9 +1,8 @@
 package handlers
 
 import (
-	"instagram-backend/cache"
+	"instagram-backend/audit"
 	"instagram-backend/models"
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,7 +15,7 @@ type LoginRequest struct {
 }
 
 // @Summary User login
-// @Description Authenticate a user and return a JWT token
+// @Description Authenticate a user and return a JWT token, or an MFA challenge token when 2FA is enabled
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -34,31 +33,73 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if result := h.db.Where("email = ?", req.Email).First(&user); result.Error != nil {
+	user, err := h.repos.Users.FindByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		recordAudit(h.repos.Audit, c, audit.Entry{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			Details:    map[string]interface{}{"email": req.Email, "reason": "unknown_email"},
+		})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		recordAudit(h.repos.Audit, c, audit.Entry{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]interface{}{"reason": "bad_password"},
+		})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	token, err := generateToken(user.ID)
+	h.respondWithLogin(c, user)
+}
+
+// respondWithLogin finishes a successful first-factor login. Accounts with TOTP
+// get a short-lived challenge instead of a JWT; the client finishes signing in
+// through /login/2fa.
+func (h *AuthHandler) respondWithLogin(c *gin.Context, user *models.User) {
+	if user.TOTPEnabled {
+		mfaToken, err := h.generatePurposeToken(user.ID, mfaChallengePurpose, mfaChallengeTTL, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfaRequired": true,
+			"mfaToken":    mfaToken,
+		})
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// completeLogin issues the JWT for an authenticated user and writes the login response.
+func (h *AuthHandler) completeLogin(c *gin.Context, user *models.User) {
+	token, err := h.startSession(c, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
+	recordAudit(h.repos.Audit, c, audit.Entry{ActorID: user.ID, Action: audit.ActionLogin, TargetType: audit.TargetUser, TargetID: user.ID})
 
 	// Cache user data after successful login
-	if err := cache.CacheUser(c.Request.Context(), &user); err != nil {
+	if err := h.cache.CacheUser(c.Request.Context(), user); err != nil {
 		// Log the error but don't fail the request
-		log.Printf("Failed to cache user data: %v", err)
+		requestLogger(c).Warn("Failed to cache user", "user_id", user.ID, "error", err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"token": token,
 		"user":  user,
-	})
-}
\ No newline at end of file
+	}
+	if user.Role == "seller" && !user.TOTPEnabled && settingEnabled(c.Request.Context(), h.repos.Settings, models.SettingRequireSellerTwoFactor) {
+		response["twoFactorSetupRequired"] = true
+	}
+
+	c.JSON(http.StatusOK, response)
+}
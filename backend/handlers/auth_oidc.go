@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/oidc"
+	"instagram-backend/repository"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9_.]+`)
+
+// OIDCHandler signs users in through external OpenID Connect providers.
+type OIDCHandler struct {
+	auth      *AuthHandler
+	providers *oidc.Registry
+	states    oidc.StateStore
+}
+
+func NewOIDCHandler(auth *AuthHandler, providers *oidc.Registry, states oidc.StateStore) *OIDCHandler {
+	return &OIDCHandler{
+		auth:      auth,
+		providers: providers,
+		states:    states,
+	}
+}
+
+// @Summary List social login providers
+// @Description List the configured OpenID Connect providers
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Router /api/v1/auth/oidc/providers [get]
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.providers.Names()})
+}
+
+// @Summary Start social login
+// @Description Redirect to the identity provider using the authorization code flow with PKCE
+// @Tags auth
+// @Param provider path string true "Provider name, e.g. google"
+// @Success 302
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/v1/auth/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	provider, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	req := provider.NewAuthRequest()
+	if err := h.states.Save(c.Request.Context(), req, oidcStateTTL); err != nil {
+		requestLogger(c).Error("Failed to save OIDC state", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(req))
+}
+
+// @Summary Finish social login
+// @Description Handle the provider callback, link or create the user and return a JWT
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name, e.g. google"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	provider, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	// Apple posts the callback as a form, other providers use the query string.
+	param := func(key string) string {
+		if v := c.PostForm(key); v != "" {
+			return v
+		}
+		return c.Query(key)
+	}
+
+	if errCode := param("error"); errCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login was not completed: " + errCode})
+		return
+	}
+
+	req, err := h.states.Take(c.Request.Context(), param("state"))
+	if err != nil || req.Provider != c.Param("provider") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), param("code"), req)
+	if err != nil {
+		requestLogger(c).Error("OIDC exchange failed", "provider", req.Provider, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to verify identity"})
+		return
+	}
+
+	user, err := h.linkIdentity(c, identity)
+	if errors.Is(err, errUnverifiedProviderEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, errUnverifiedLocalEmail) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		requestLogger(c).Error("Failed to link external identity", "provider", req.Provider, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+
+	h.auth.respondWithLogin(c, user)
+}
+
+func (h *OIDCHandler) provider(c *gin.Context) (*oidc.Provider, bool) {
+	provider, err := h.providers.Get(c.Request.Context(), c.Param("provider"))
+	if errors.Is(err, oidc.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return nil, false
+	}
+	if err != nil {
+		requestLogger(c).Error("Failed to load identity provider", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+		return nil, false
+	}
+	return provider, true
+}
+
+var (
+	errUnverifiedProviderEmail = errors.New("The identity provider did not confirm your email address")
+	errUnverifiedLocalEmail    = errors.New("An account with this email address exists but hasn't verified it. Verify the email address or sign in with your password first")
+)
+
+// linkIdentity resolves the local user for an external identity. Known
+// identities map straight to their user; otherwise a verified email links to an
+// existing account whose email is verified too, or a new buyer account is
+// created. An unverified account may have been registered by someone else
+// ahead of the email's owner, who would then share it, so it is never linked.
+func (h *OIDCHandler) linkIdentity(c *gin.Context, identity *oidc.Identity) (*models.User, error) {
+	ctx := c.Request.Context()
+	repos := h.auth.repos
+
+	existing, err := repos.Identities.Find(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return repos.Users.FindByID(ctx, existing.UserID)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	// Linking by email is only safe when the provider vouches for it.
+	if !identity.EmailVerified || identity.Email == "" {
+		return nil, errUnverifiedProviderEmail
+	}
+
+	var user *models.User
+	created := false
+	err = repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		var err error
+		user, err = tx.Users.FindByEmailIgnoreCase(ctx, identity.Email)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			if user, err = createUserForIdentity(ctx, tx.Users, identity); err != nil {
+				return err
+			}
+			created = true
+			if err := recordAudit(tx.Audit, c, audit.Entry{
+				ActorID:    user.ID,
+				Action:     audit.ActionRegister,
+				TargetType: audit.TargetUser,
+				TargetID:   user.ID,
+				Details:    map[string]interface{}{"provider": identity.Provider},
+			}); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case !user.EmailVerified:
+			return errUnverifiedLocalEmail
+		}
+
+		if err := tx.Identities.Create(ctx, &models.ExternalIdentity{
+			UserID:   user.ID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			ActorID:    user.ID,
+			Action:     audit.ActionIdentityLinked,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]interface{}{"provider": identity.Provider, "email": identity.Email},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		h.auth.metrics.Registered(identity.Provider)
+	}
+	return user, nil
+}
+
+// createUserForIdentity registers a buyer account with an unusable password;
+// the user signs in through the provider from then on.
+func createUserForIdentity(ctx context.Context, users repository.UserRepository, identity *oidc.Identity) (*models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomToken(32)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	base := usernameUnsafeChars.ReplaceAllString(strings.ToLower(strings.SplitN(identity.Email, "@", 2)[0]), "")
+	if len(base) > 25 {
+		base = base[:25]
+	}
+	if validateHandle(base) != nil {
+		base = "user"
+	}
+	username := base
+	for i := 0; i < 5; i++ {
+		available, err := users.UsernameAvailable(ctx, username, 0)
+		if err != nil {
+			return nil, err
+		}
+		if available {
+			break
+		}
+		username = base + "_" + randomToken(2)
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = base
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Username:        username,
+		Email:           identity.Email,
+		Password:        string(hashedPassword),
+		Name:            name,
+		Role:            "buyer",
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
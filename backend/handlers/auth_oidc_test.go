@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"instagram-backend/cache"
+	"instagram-backend/config"
+	"instagram-backend/keys"
+	"instagram-backend/mailer"
+	"instagram-backend/models"
+	"instagram-backend/oidc"
+	"instagram-backend/oidc/oidctest"
+	"instagram-backend/repository"
+	"instagram-backend/service"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newOIDCTestRouter(t *testing.T) (*gin.Engine, *oidctest.Provider, *gorm.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.ExternalIdentity{}, &models.Setting{}, &models.RecoveryCode{}, &models.Session{}, &models.SigningKey{}, &models.UsernameRedirect{}, &models.AuditEvent{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	redisCache := cache.New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), cache.Options{})
+
+	mock := oidctest.NewProvider("client-id", "client-secret")
+	t.Cleanup(mock.Close)
+
+	registry := oidc.NewRegistry(oidc.Config{
+		Name:         "mock",
+		Issuer:       mock.Issuer(),
+		ClientID:     mock.ClientID,
+		ClientSecret: mock.ClientSecret,
+		RedirectURL:  "http://localhost/auth/oidc/mock/callback",
+	})
+	ks, err := keys.NewKeySet(context.Background(), db, keys.Options{
+		Algorithm:        keys.AlgEdDSA,
+		RotationInterval: time.Hour,
+		PrePublish:       time.Minute,
+		Overlap:          time.Hour,
+		EncryptionKey:    make([]byte, 32),
+	})
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	repos := repository.NewGorm(db)
+	h := NewOIDCHandler(NewAuthHandler(config.Default(), repos, service.New(repos), redisCache, mailer.LogMailer{}, ks, nil, nil), registry, oidc.NewMemoryStateStore())
+
+	r := gin.New()
+	r.GET("/auth/oidc/:provider/login", h.Login)
+	r.GET("/auth/oidc/:provider/callback", h.Callback)
+	return r, mock, db
+}
+
+// oidcLogin runs the whole redirect round trip against the mock provider.
+func oidcLogin(t *testing.T, r *gin.Engine) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d, body %s", w.Code, w.Body)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse callback: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?"+callback.RawQuery, nil))
+	return w
+}
+
+func TestOIDCCallbackCreatesUser(t *testing.T) {
+	r, mock, db := newOIDCTestRouter(t)
+	mock.SetUser(oidctest.User{Subject: "sub-1", Email: "new@example.com", EmailVerified: true, Name: "New User"})
+
+	w := oidcLogin(t, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("callback status = %d, body %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Token string      `json:"token"`
+		User  models.User `json:"user"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Token == "" || body.User.Email != "new@example.com" || body.User.Role != "buyer" || !body.User.EmailVerified {
+		t.Fatalf("unexpected response %s", w.Body)
+	}
+
+	var count int64
+	db.Model(&models.ExternalIdentity{}).Where("provider = ? AND subject = ?", "mock", "sub-1").Count(&count)
+	if count != 1 {
+		t.Fatalf("identities = %d, want 1", count)
+	}
+
+	// Signing in again reuses the linked account.
+	if w := oidcLogin(t, r); w.Code != http.StatusOK {
+		t.Fatalf("second callback status = %d, body %s", w.Code, w.Body)
+	}
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("users = %d, want 1", count)
+	}
+}
+
+func TestOIDCCallbackLinksExistingUserByVerifiedEmail(t *testing.T) {
+	r, mock, db := newOIDCTestRouter(t)
+	existing := models.User{Username: "jane", Email: "jane@example.com", Password: "x", Name: "Jane", Role: "seller", EmailVerified: true}
+	db.Create(&existing)
+	mock.SetUser(oidctest.User{Subject: "sub-2", Email: "Jane@example.com", EmailVerified: true})
+
+	if w := oidcLogin(t, r); w.Code != http.StatusOK {
+		t.Fatalf("callback status = %d, body %s", w.Code, w.Body)
+	}
+
+	var identity models.ExternalIdentity
+	if err := db.Where("subject = ?", "sub-2").First(&identity).Error; err != nil {
+		t.Fatalf("identity not linked: %v", err)
+	}
+	if identity.UserID != existing.ID {
+		t.Fatalf("linked to user %d, want %d", identity.UserID, existing.ID)
+	}
+}
+
+func TestOIDCCallbackRefusesUnverifiedLocalAccount(t *testing.T) {
+	r, mock, db := newOIDCTestRouter(t)
+	// Whoever registered the address first never proved they own it
+	squatter := models.User{Username: "jane", Email: "jane@example.com", Password: "squatter", Role: "buyer"}
+	db.Create(&squatter)
+	mock.SetUser(oidctest.User{Subject: "sub-3", Email: "jane@example.com", EmailVerified: true})
+
+	if w := oidcLogin(t, r); w.Code != http.StatusConflict {
+		t.Fatalf("callback status = %d, want %d, body %s", w.Code, http.StatusConflict, w.Body)
+	}
+
+	var count int64
+	db.Model(&models.ExternalIdentity{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("identities = %d, want 0", count)
+	}
+	var user models.User
+	db.First(&user, squatter.ID)
+	if user.EmailVerified {
+		t.Fatal("unverified account was marked verified")
+	}
+}
+
+func TestOIDCCallbackRefusesUnverifiedEmail(t *testing.T) {
+	r, mock, db := newOIDCTestRouter(t)
+	db.Create(&models.User{Username: "victim", Email: "victim@example.com", Password: "x", Role: "seller"})
+	mock.SetUser(oidctest.User{Subject: "attacker", Email: "victim@example.com", EmailVerified: false})
+
+	if w := oidcLogin(t, r); w.Code != http.StatusForbidden {
+		t.Fatalf("callback status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	var count int64
+	db.Model(&models.ExternalIdentity{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("identities = %d, want 0", count)
+	}
+}
+
+func TestOIDCCallbackRejectsUnknownState(t *testing.T) {
+	r, _, _ := newOIDCTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/mock/callback?code=abc&state=forged", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("callback status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestOIDCLoginUnknownProvider(t *testing.T) {
+	r, _, _ := newOIDCTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/nope/login", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("login status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
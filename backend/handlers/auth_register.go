@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"context"
+	"instagram-backend/audit"
+	"instagram-backend/logging"
 	"instagram-backend/models"
 	"net/http"
 
@@ -9,7 +12,7 @@ import (
 )
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
+	Username string `json:"username" binding:"required"` // sellers: "<category-slug>/<handle>"
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 	Name     string `json:"name" binding:"required"`
@@ -38,16 +41,27 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	username, category, err := validateUsername(c.Request.Context(), h.repos.Categories, req.Username, req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Previous usernames stay reserved for the accounts that held them
+	if available, err := h.repos.Users.UsernameAvailable(c.Request.Context(), username, 0); err != nil || !available {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		return
+	}
+
 	// Create channels for parallel processing
-	existingUserChan := make(chan error)
+	existingUserChan := make(chan bool)
 	hashedPasswordChan := make(chan []byte)
 	errorChan := make(chan error)
 
 	// Check if user exists in parallel
 	go func() {
-		var existingUser models.User
-		result := h.db.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser)
-		existingUserChan <- result.Error
+		exists, err := h.repos.Users.Exists(c.Request.Context(), req.Email, username)
+		existingUserChan <- err == nil && exists
 	}()
 
 	// Hash password in parallel
@@ -61,7 +75,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}()
 
 	// Wait for user check
-	if err := <-existingUserChan; err == nil {
+	if <-existingUserChan {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
 	}
@@ -74,20 +88,34 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	case hashedPassword := <-hashedPasswordChan:
 		// Create user with the hashed password
 		user := models.User{
-			Username: req.Username,
+			Username: username,
 			Email:    req.Email,
 			Password: string(hashedPassword),
 			Name:     req.Name,
 			Role:     req.Role,
 		}
+		if category != nil {
+			user.CategoryID = &category.ID
+		}
 
-		if result := h.db.Create(&user); result.Error != nil {
+		if err := h.repos.Users.Create(c.Request.Context(), &user); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 			return
 		}
+		h.metrics.Registered("password")
+		recordAudit(h.repos.Audit, c, audit.Entry{ActorID: user.ID, Action: audit.ActionRegister, TargetType: audit.TargetUser, TargetID: user.ID})
+
+		// Send the verification link without holding up the response
+		// It outlives the request, so it keeps only the request's values
+		ctx := context.WithoutCancel(c.Request.Context())
+		go func(user models.User) {
+			if err := h.sendVerificationEmail(ctx, &user); err != nil {
+				logging.FromContext(ctx).Error("Failed to send verification email", "user_id", user.ID, "error", err)
+			}
+		}(user)
 
 		// Generate token
-		token, err := generateToken(user.ID)
+		token, err := h.startSession(c, user.ID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 			return
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startSession records the device signing in and returns a token bound to it.
+func (h *AuthHandler) startSession(c *gin.Context, userID uint) (string, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:     userID,
+		DeviceName: deviceName(c),
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(h.cfg.JWT.TokenLifetime),
+	}
+	if err := h.repos.Sessions.Create(c.Request.Context(), &session); err != nil {
+		return "", err
+	}
+	return h.generateToken(&session)
+}
+
+// deviceName prefers the name the app sends in X-Device-Name and otherwise
+// makes a rough guess from the user agent.
+func deviceName(c *gin.Context) string {
+	if name := strings.TrimSpace(c.GetHeader("X-Device-Name")); name != "" {
+		if len(name) > 100 {
+			name = name[:100]
+		}
+		return name
+	}
+
+	ua := strings.ToLower(c.Request.UserAgent())
+	switch {
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "android"):
+		return "Android device"
+	case strings.Contains(ua, "dart"):
+		return "Mobile app"
+	case strings.Contains(ua, "macintosh"):
+		return "Mac"
+	case strings.Contains(ua, "windows"):
+		return "Windows PC"
+	case strings.Contains(ua, "linux"):
+		return "Linux PC"
+	}
+	return "Unknown device"
+}
+
+// @Summary List sessions
+// @Description List the devices where the current user is signed in
+// @Tags sessions
+// @Produce json
+// @Success 200 {array} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/v1/me/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.repos.Sessions.ListActive(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	currentID := c.GetUint("session_id")
+	response := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, gin.H{
+			"id":         s.ID,
+			"deviceName": s.DeviceName,
+			"userAgent":  s.UserAgent,
+			"ipAddress":  s.IPAddress,
+			"createdAt":  s.CreatedAt,
+			"lastSeenAt": s.LastSeenAt,
+			"expiresAt":  s.ExpiresAt,
+			"current":    s.ID == currentID,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Sign out a device
+// @Description Revoke one of the current user's sessions
+// @Tags sessions
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	sessionID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	revoked, err := h.repos.Sessions.Revoke(c.Request.Context(), sessionID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{Action: audit.ActionSessionRevoke, TargetType: audit.TargetSession, TargetID: sessionID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// @Summary Sign out other devices
+// @Description Revoke every session of the current user except the one making the request
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/v1/me/sessions [delete]
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	revoked, err := h.repos.Sessions.RevokeAll(c.Request.Context(), c.GetUint("user_id"), c.GetUint("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{
+		Action:     audit.ActionSessionRevoke,
+		TargetType: audit.TargetUser,
+		TargetID:   c.GetUint("user_id"),
+		Details:    map[string]interface{}{"allOtherSessions": true, "revoked": revoked},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Signed out of all other devices",
+		"revoked": revoked,
+	})
+}
@@ -1,40 +1,68 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"instagram-backend/models"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-func generateToken(userOrID interface{}) (string, error) {
-	expiryDays, _ := strconv.Atoi(os.Getenv("JWT_EXPIRY_DAYS"))
-	if expiryDays <= 0 {
-		expiryDays = 7 // Default value
-	}
+// generateToken signs the login token for a session. AuthMiddleware checks the
+// "sid" claim against the sessions table so the token dies with its session.
+func (h *AuthHandler) generateToken(session *models.Session) (string, error) {
+	return h.keys.Sign(jwt.MapClaims{
+		"user_id": session.UserID,
+		"sid":     session.ID,
+		"exp":     session.ExpiresAt.Unix(),
+	})
+}
 
+// generatePurposeToken issues a short-lived token that is only accepted by the
+// flow named by purpose. It carries no user_id claim, so AuthMiddleware rejects it.
+func (h *AuthHandler) generatePurposeToken(userID uint, purpose string, ttl time.Duration, extra jwt.MapClaims) (string, error) {
 	claims := jwt.MapClaims{
-		"exp": time.Now().Add(time.Hour * 24 * time.Duration(expiryDays)).Unix(),
+		"sub":     strconv.FormatUint(uint64(userID), 10),
+		"purpose": purpose,
+		"jti":     randomToken(16),
+		"exp":     time.Now().Add(ttl).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return h.keys.Sign(claims)
+}
+
+// parsePurposeToken validates a token from generatePurposeToken and returns the
+// user ID and remaining claims it was issued with.
+func (h *AuthHandler) parsePurposeToken(tokenString, purpose string) (uint, jwt.MapClaims, error) {
+	token, err := h.keys.Parse(tokenString)
+	if err != nil || !token.Valid {
+		return 0, nil, fmt.Errorf("invalid token")
 	}
 
-	switch v := userOrID.(type) {
-	case uint:
-		claims["user_id"] = v
-	case *models.User:
-		claims["user_id"] = v.ID
-		claims["role"] = v.Role
-	default:
-		return "", fmt.Errorf("invalid argument type for generateToken")
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != purpose {
+		return 0, nil, fmt.Errorf("invalid token")
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		secretKey = "your-secret-key-here" // Default value
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.ParseUint(sub, 10, 32)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid token")
 	}
 
-	return token.SignedString([]byte(secretKey))
-}
\ No newline at end of file
+	return uint(userID), claims, nil
+}
+
+// randomToken returns n random bytes encoded as hex.
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaChallengePurpose = "mfa_challenge"
+	mfaChallengeTTL     = 5 * time.Minute
+	mfaMaxAttempts      = 5
+	totpPeriod          = 30 * time.Second
+	recoveryCodeCount   = 10
+)
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+type LoginTwoFactorRequest struct {
+	MFAToken     string `json:"mfaToken" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// @Summary Start TOTP enrollment
+// @Description Generate a new authenticator secret and otpauth URI for the current user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/2fa/totp/enroll [post]
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	user, err := h.repos.Users.FindByID(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      h.cfg.Auth.TOTPIssuer,
+		AccountName: user.Email,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	// The secret stays inactive until ConfirmTOTP sees a valid code.
+	user.TOTPSecret = key.Secret()
+	if err := h.repos.Users.Update(c.Request.Context(), user, "TOTPSecret"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":     key.Secret(),
+		"otpauthUri": key.URL(),
+	})
+}
+
+// @Summary Confirm TOTP enrollment
+// @Description Verify a code from the authenticator app, enable 2FA and return recovery codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param code body TOTPCodeRequest true "Authenticator code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/2fa/totp/verify [post]
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.repos.Users.FindByID(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start enrollment first"})
+		return
+	}
+
+	if !h.acceptTOTP(c.Request.Context(), user, req.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var codes []string
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		user.TOTPEnabled = true
+		if err := tx.Users.Update(ctx, user, "TOTPEnabled"); err != nil {
+			return err
+		}
+		var err error
+		if codes, err = replaceRecoveryCodes(ctx, tx.RecoveryCodes, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{Action: audit.ActionTOTPEnabled, TargetType: audit.TargetUser, TargetID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": codes,
+	})
+}
+
+// @Summary Disable TOTP
+// @Description Turn off two-factor authentication after re-checking the password and a code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body DisableTOTPRequest true "Password and TOTP or recovery code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/2fa/totp [delete]
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	var req DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.repos.Users.FindByID(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	if user.Role == "seller" && settingEnabled(c.Request.Context(), h.repos.Settings, models.SettingRequireSellerTwoFactor) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for sellers"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	if !h.acceptTOTP(c.Request.Context(), user, req.Code) && !h.useRecoveryCode(c.Request.Context(), user.ID, req.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		user.TOTPEnabled, user.TOTPSecret, user.TOTPLastStep = false, "", nil
+		if err := tx.Users.Update(ctx, user, "TOTPEnabled", "TOTPSecret", "TOTPLastStep"); err != nil {
+			return err
+		}
+		if err := tx.RecoveryCodes.DeleteAll(ctx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{Action: audit.ActionTOTPDisabled, TargetType: audit.TargetUser, TargetID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// @Summary Complete two-step login
+// @Description Exchange the MFA challenge token from /login and a TOTP or recovery code for a JWT
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body LoginTwoFactorRequest true "Challenge token and code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /api/v1/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	// Apply rate limiting
+	<-h.rateLimit.C
+
+	var req LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recoveryCode is required"})
+		return
+	}
+
+	userID, claims, err := h.parsePurposeToken(req.MFAToken, mfaChallengePurpose)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	// Cap guesses per challenge so the 6-digit space cannot be brute forced.
+	// Without Redis there is no cap, so refuse rather than allow unlimited
+	// guesses.
+	jti, _ := claims["jti"].(string)
+	attemptsKey := "mfa_attempts:" + jti
+	redisClient := h.cache.Redis()
+	attempts, err := redisClient.Incr(c.Request.Context(), attemptsKey).Result()
+	if err != nil {
+		requestLogger(c).Error("Failed to count MFA attempts", "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Two-factor login is temporarily unavailable"})
+		return
+	}
+	redisClient.Expire(c.Request.Context(), attemptsKey, mfaChallengeTTL)
+	if attempts > mfaMaxAttempts {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts, please log in again"})
+		return
+	}
+
+	user, err := h.repos.Users.FindByID(c.Request.Context(), userID)
+	if err != nil || !user.TOTPEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	valid := false
+	if req.Code != "" {
+		valid = h.acceptTOTP(c.Request.Context(), user, req.Code)
+	} else {
+		valid = h.useRecoveryCode(c.Request.Context(), user.ID, req.RecoveryCode)
+	}
+	if !valid {
+		recordAudit(h.repos.Audit, c, audit.Entry{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]interface{}{"reason": "bad_mfa_code"},
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	// A challenge is good for one session; the attempt counter is left to
+	// expire with it.
+	fresh, err := redisClient.SetNX(c.Request.Context(), "mfa_used:"+jti, 1, mfaChallengeTTL).Result()
+	if err != nil {
+		requestLogger(c).Error("Failed to mark MFA challenge used", "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Two-factor login is temporarily unavailable"})
+		return
+	}
+	if !fresh {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// acceptTOTP checks code against the user's authenticator, allowing one step
+// of clock skew either way, and records the time step it matched. A step at
+// or before the last one recorded is refused, so each code works only once.
+func (h *AuthHandler) acceptTOTP(ctx context.Context, user *models.User, code string) bool {
+	code = strings.TrimSpace(code)
+	now := time.Now()
+	for skew := -1; skew <= 1; skew++ {
+		at := now.Add(time.Duration(skew) * totpPeriod)
+		expected, err := totp.GenerateCode(user.TOTPSecret, at)
+		if err != nil {
+			return false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		step := at.Unix() / int64(totpPeriod/time.Second)
+		accepted, err := h.repos.Users.AcceptTOTPStep(ctx, user.ID, step)
+		return err == nil && accepted
+	}
+	return false
+}
+
+// replaceRecoveryCodes discards the user's existing recovery codes and stores a
+// fresh set, returning the plaintext codes to show once.
+func replaceRecoveryCodes(ctx context.Context, recoveryCodes repository.RecoveryCodeRepository, userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: string(hash)}
+	}
+
+	if err := recoveryCodes.Replace(ctx, userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a code formatted as two groups of five characters.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// useRecoveryCode marks a matching unused recovery code as used.
+func (h *AuthHandler) useRecoveryCode(ctx context.Context, userID uint, code string) bool {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		return false
+	}
+
+	records, err := h.repos.RecoveryCodes.ListUnused(ctx, userID)
+	if err != nil {
+		return false
+	}
+
+	for _, record := range records {
+		if bcrypt.CompareHashAndPassword([]byte(record.CodeHash), []byte(code)) == nil {
+			used, err := h.repos.RecoveryCodes.Use(ctx, record.ID)
+			return err == nil && used
+		}
+	}
+	return false
+}
@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"instagram-backend/cache"
-	"instagram-backend/models"
-	"log"
+	"instagram-backend/audit"
 	"net/http"
 	"strconv"
 
@@ -23,8 +21,13 @@ import (
 func (h *AuthHandler) GetUser(c *gin.Context) {
 	userID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 
+	if err := h.users.CheckNotBlocked(c.Request.Context(), c.GetUint("user_id"), uint(userID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
 	// Try to get user from cache first
-	cachedUser, err := cache.GetCachedUser(c.Request.Context(), uint(userID))
+	cachedUser, err := h.cache.GetCachedUser(c.Request.Context(), uint(userID))
 	if err == nil {
 		c.Header("X-Cache", "HIT")
 		c.Header("Cache-Control", "private, max-age=300")
@@ -32,15 +35,15 @@ func (h *AuthHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
+	user, err := h.users.Get(c.Request.Context(), c.GetUint("user_id"), uint(userID))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	// Cache the user for future requests
-	if err := cache.CacheUser(c.Request.Context(), &user); err != nil {
-		log.Printf("Failed to cache user: %v", err)
+	if err := h.cache.CacheUser(c.Request.Context(), user); err != nil {
+		requestLogger(c).Warn("Failed to cache user", "user_id", user.ID, "error", err)
 	}
 
 	c.Header("Cache-Control", "private, max-age=300")
@@ -62,10 +65,14 @@ func (h *AuthHandler) GetUser(c *gin.Context) {
 // @Router /api/users/{id} [put]
 func (h *AuthHandler) UpdateUser(c *gin.Context) {
 	userID := c.GetUint("user_id")
-	paramID := c.Param("id")
+	paramID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 
-	var user models.User
-	if err := h.db.First(&user, paramID).Error; err != nil {
+	user, err := h.repos.Users.FindByID(c.Request.Context(), paramID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -86,18 +93,22 @@ func (h *AuthHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	before := *user
 	user.Name = updateData.Name
 	user.Bio = updateData.Bio
 	user.ProfileImage = updateData.ProfileImage
 
-	if err := h.db.Save(&user).Error; err != nil {
+	if err := h.repos.Users.Update(c.Request.Context(), user, "Name", "Bio", "ProfileImage"); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
+	if changes := audit.Diff(before, *user); len(changes) > 0 {
+		recordAudit(h.repos.Audit, c, audit.Entry{Action: audit.ActionUserUpdate, TargetType: audit.TargetUser, TargetID: user.ID, Changes: changes})
+	}
 
 	// Invalidate the user cache after update
-	if err := cache.InvalidateUserCache(c.Request.Context(), user.ID); err != nil {
-		log.Printf("Failed to invalidate user cache: %v", err)
+	if err := h.cache.InvalidateUserCache(c.Request.Context(), user.ID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", user.ID, "error", err)
 	}
 
 	c.JSON(http.StatusOK, user)
@@ -108,12 +119,14 @@ func (h *AuthHandler) GetUserSubscribers(c *gin.Context) {
 	// Apply rate limiting
 	<-h.rateLimit.C
 
-	// Use preloading and indexing for better performance
-	var subscribers []models.User
-	if err := h.db.Select("DISTINCT users.*").
-		Joins("JOIN subscriptions ON users.id = subscriptions.subscriber_id").
-		Where("subscriptions.seller_id = ?", c.Param("id")).
-		Find(&subscribers).Error; err != nil {
+	sellerID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	subscribers, err := h.repos.Subscriptions.ListSubscribers(c.Request.Context(), sellerID, c.GetUint("user_id"))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscribers"})
 		return
 	}
@@ -125,9 +138,14 @@ func (h *AuthHandler) GetUserSubscribers(c *gin.Context) {
 
 // GetUserSubscriptions fetches the list of sellers a buyer is subscribed to.
 func (h *AuthHandler) GetUserSubscriptions(c *gin.Context) {
-	var subscriptions []models.User
-	if err := h.db.Joins("JOIN subscriptions ON users.id = subscriptions.seller_id").
-		Where("subscriptions.subscriber_id = ?", c.Param("id")).Find(&subscriptions).Error; err != nil {
+	subscriberID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	subscriptions, err := h.repos.Subscriptions.ListSellers(c.Request.Context(), subscriberID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
 		return
 	}
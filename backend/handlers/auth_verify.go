@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"instagram-backend/audit"
+	"instagram-backend/mailer"
+	"instagram-backend/models"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const emailVerificationPurpose = "email_verification"
+
+// generateVerificationToken signs a token binding the user to the email address
+// it was issued for, so changing the address invalidates outstanding links.
+func (h *AuthHandler) generateVerificationToken(user *models.User) (string, error) {
+	return h.generatePurposeToken(user.ID, emailVerificationPurpose, h.cfg.Auth.EmailVerificationTTL,
+		jwt.MapClaims{"email": user.Email})
+}
+
+// sendVerificationEmail emails the user a signed verification link and records
+// when it was sent so resends can be throttled.
+func (h *AuthHandler) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := h.generateVerificationToken(user)
+	if err != nil {
+		return err
+	}
+
+	link := h.cfg.Server.BaseURL + "/api/v1/verify-email?token=" + url.QueryEscape(token)
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\n"+
+			"If you did not create an account you can ignore this email.\n", user.Name, link),
+	}
+	if err := h.mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.VerificationSentAt = &now
+	return h.repos.Users.Update(ctx, user, "VerificationSentAt")
+}
+
+// @Summary Verify email address
+// @Description Confirm a user's email address using the signed link sent at registration
+// @Tags auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/verify-email [get]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	userID, claims, err := h.parsePurposeToken(c.Query("token"), emailVerificationPurpose)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+	email, _ := claims["email"].(string)
+
+	user, err := h.repos.Users.FindByID(c.Request.Context(), userID)
+	if err != nil || user.Email != email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	if user.EmailVerified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email already verified"})
+		return
+	}
+
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	if err := h.repos.Users.Update(c.Request.Context(), user, "EmailVerified", "EmailVerifiedAt"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{
+		ActorID:    user.ID,
+		Action:     audit.ActionEmailVerified,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Details:    map[string]interface{}{"email": user.Email},
+	})
+
+	if err := h.cache.InvalidateUserCache(c.Request.Context(), user.ID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", user.ID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// @Summary Resend verification email
+// @Description Send a new verification link to the authenticated user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string "Resend throttled"
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/verify-email/resend [post]
+func (h *AuthHandler) ResendVerificationEmail(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	user, err := h.repos.Users.FindByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.EmailVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already verified"})
+		return
+	}
+
+	if user.VerificationSentAt != nil {
+		if wait := h.cfg.Auth.VerificationResendCooldown - time.Since(*user.VerificationSentAt); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Verification email sent recently, please try again later"})
+			return
+		}
+	}
+
+	if err := h.sendVerificationEmail(c.Request.Context(), user); err != nil {
+		requestLogger(c).Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
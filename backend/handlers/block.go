@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserRelationRequest struct {
+	UserID uint `json:"userId" binding:"required"`
+}
+
+// @Summary List blocked users
+// @Description List the users the current user has blocked
+// @Tags blocking
+// @Produce json
+// @Success 200 {array} models.Block
+// @Security BearerAuth
+// @Router /api/v1/me/blocked [get]
+func (h *AuthHandler) ListBlocked(c *gin.Context) {
+	blocks, err := h.repos.Blocks.ListBlocked(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocked users"})
+		return
+	}
+	c.JSON(http.StatusOK, blocks)
+}
+
+// @Summary Block user
+// @Description Block a user. Neither user can see the other's profile or posts, comment, like or subscribe, and existing subscriptions between them are removed.
+// @Tags blocking
+// @Accept json
+// @Produce json
+// @Param user body UserRelationRequest true "User to block"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/blocked [post]
+func (h *AuthHandler) BlockUser(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	target, ok := h.relationTarget(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Blocks.Block(ctx, userID, target.ID); err != nil {
+			return err
+		}
+		return tx.Subscriptions.DeleteBetween(ctx, userID, target.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "User blocked"})
+}
+
+// @Summary Unblock user
+// @Description Remove a block. Subscriptions removed by the block are not restored.
+// @Tags blocking
+// @Produce json
+// @Param id path int true "Blocked user ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/blocked/{id} [delete]
+func (h *AuthHandler) UnblockUser(c *gin.Context) {
+	blockedID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+		return
+	}
+	unblocked, err := h.repos.Blocks.Unblock(c.Request.Context(), c.GetUint("user_id"), blockedID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+
+	if !unblocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// @Summary List muted users
+// @Description List the users whose posts are hidden from the current user's feed
+// @Tags blocking
+// @Produce json
+// @Success 200 {array} models.Mute
+// @Security BearerAuth
+// @Router /api/v1/me/muted [get]
+func (h *AuthHandler) ListMuted(c *gin.Context) {
+	mutes, err := h.repos.Blocks.ListMuted(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch muted users"})
+		return
+	}
+	c.JSON(http.StatusOK, mutes)
+}
+
+// @Summary Mute user
+// @Description Hide a user's posts from the current user's feed. The muted user is not told.
+// @Tags blocking
+// @Accept json
+// @Produce json
+// @Param user body UserRelationRequest true "User to mute"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/muted [post]
+func (h *AuthHandler) MuteUser(c *gin.Context) {
+	target, ok := h.relationTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Blocks.Mute(c.Request.Context(), c.GetUint("user_id"), target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mute user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "User muted"})
+}
+
+// @Summary Unmute user
+// @Description Show a muted user's posts in the feed again
+// @Tags blocking
+// @Produce json
+// @Param id path int true "Muted user ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/muted/{id} [delete]
+func (h *AuthHandler) UnmuteUser(c *gin.Context) {
+	mutedID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not muted"})
+		return
+	}
+	unmuted, err := h.repos.Blocks.Unmute(c.Request.Context(), c.GetUint("user_id"), mutedID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmute user"})
+		return
+	}
+
+	if !unmuted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not muted"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unmuted"})
+}
+
+// relationTarget binds a UserRelationRequest and loads the user it names.
+func (h *AuthHandler) relationTarget(c *gin.Context) (*models.User, bool) {
+	var req UserRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if req.UserID == c.GetUint("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block or mute yourself"})
+		return nil, false
+	}
+
+	target, err := h.repos.Users.FindByID(c.Request.Context(), req.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return target, true
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler struct {
+	repos *repository.Repositories
+}
+
+func NewCategoryHandler(repos *repository.Repositories) *CategoryHandler {
+	return &CategoryHandler{repos: repos}
+}
+
+type CreateCategoryRequest struct {
+	Slug        string `json:"slug" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// CategorySeller is what a category's public seller listing shows of each
+// seller.
+type CategorySeller struct {
+	ID           uint   `json:"id"`
+	Username     string `json:"username"`
+	Name         string `json:"name"`
+	ProfileImage string `json:"profileImage"`
+	Verified     bool   `json:"verified"`
+}
+
+// @Summary List categories
+// @Description List the storefront categories sellers can register under
+// @Tags categories
+// @Produce json
+// @Success 200 {array} models.Category
+// @Router /api/v1/categories [get]
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	categories, err := h.repos.Categories.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, categories)
+}
+
+// @Summary List sellers in a category
+// @Description Get a paginated list of sellers registered under a category
+// @Tags categories
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {array} CategorySeller
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/categories/{slug}/sellers [get]
+func (h *CategoryHandler) GetCategorySellers(c *gin.Context) {
+	category, err := h.repos.Categories.FindBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	sellers, err := h.repos.Users.ListSellers(c.Request.Context(), category.ID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sellers"})
+		return
+	}
+
+	listed := make([]CategorySeller, 0, len(sellers))
+	for _, seller := range sellers {
+		listed = append(listed, CategorySeller{
+			ID:           seller.ID,
+			Username:     seller.Username,
+			Name:         seller.Name,
+			ProfileImage: seller.ProfileImage,
+			Verified:     seller.Verified,
+		})
+	}
+
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, listed)
+}
+
+// @Summary Create category
+// @Description Add a storefront category that sellers can use as their username prefix
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param category body CreateCategoryRequest true "Category"
+// @Success 201 {object} models.Category
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/categories [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if err := validateHandle(slug); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.repos.Categories.FindBySlug(c.Request.Context(), slug); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category already exists"})
+		return
+	}
+
+	category := models.Category{Slug: slug, Name: req.Name, Description: req.Description}
+	if err := h.repos.Categories.Create(c.Request.Context(), &category); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{Action: audit.ActionCategoryCreate, TargetType: audit.TargetCategory, TargetID: category.ID})
+
+	c.JSON(http.StatusCreated, category)
+}
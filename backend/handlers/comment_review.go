@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BlockedKeywordRequest struct {
+	Keyword string `json:"keyword" binding:"required,max=100"`
+}
+
+// heldComment finds a held comment on one of the current user's posts.
+func (h *PostHandler) heldComment(c *gin.Context) (*models.Comment, bool) {
+	commentID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Held comment not found"})
+		return nil, false
+	}
+	comment, err := h.repos.Comments.FindHeld(c.Request.Context(), commentID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Held comment not found"})
+		return nil, false
+	}
+	return comment, true
+}
+
+// @Summary List held comments
+// @Description List comments on the current user's posts that the content filter held for review
+// @Tags comments
+// @Produce json
+// @Success 200 {array} models.Comment
+// @Security BearerAuth
+// @Router /api/v1/me/held-comments [get]
+func (h *PostHandler) ListHeldComments(c *gin.Context) {
+	comments, err := h.repos.Comments.ListHeld(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch held comments"})
+		return
+	}
+	c.JSON(http.StatusOK, comments)
+}
+
+// @Summary Approve held comment
+// @Description Publish a comment the content filter held on one of the current user's posts
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} models.Comment
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/held-comments/{id}/approve [post]
+func (h *PostHandler) ApproveHeldComment(c *gin.Context) {
+	comment, ok := h.heldComment(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Comments.Publish(c.Request.Context(), comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve comment"})
+		return
+	}
+
+	// The post embeds its comments in the cache
+	if err := h.cache.InvalidatePostCache(c.Request.Context(), comment.PostID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate post cache", "post_id", comment.PostID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// @Summary Reject held comment
+// @Description Delete a comment the content filter held on one of the current user's posts
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/held-comments/{id} [delete]
+func (h *PostHandler) RejectHeldComment(c *gin.Context) {
+	comment, ok := h.heldComment(c)
+	if !ok {
+		return
+	}
+
+	// Deleted by the seller, so it doesn't show up in the commenter's trash
+	ctx := c.Request.Context()
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Comments.Trash(ctx, comment.ID, c.GetUint("user_id")); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionCommentDelete,
+			TargetType: audit.TargetComment,
+			TargetID:   comment.ID,
+			Details:    map[string]interface{}{"postId": comment.PostID, "heldReason": comment.FilterReason},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+}
+
+// @Summary List blocked keywords
+// @Description List the keywords that hold comments on the current user's posts for review
+// @Tags comments
+// @Produce json
+// @Success 200 {array} models.BlockedKeyword
+// @Security BearerAuth
+// @Router /api/v1/me/blocked-keywords [get]
+func (h *PostHandler) ListBlockedKeywords(c *gin.Context) {
+	keywords, err := h.repos.Keywords.List(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocked keywords"})
+		return
+	}
+	c.JSON(http.StatusOK, keywords)
+}
+
+// @Summary Add blocked keyword
+// @Description Hold comments containing a word or phrase on the current user's posts for review
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param keyword body BlockedKeywordRequest true "Keyword"
+// @Success 201 {object} models.BlockedKeyword
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/blocked-keywords [post]
+func (h *PostHandler) AddBlockedKeyword(c *gin.Context) {
+	var req BlockedKeywordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	keyword := strings.ToLower(strings.TrimSpace(req.Keyword))
+	if keyword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Keyword is required"})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	if exists, _ := h.repos.Keywords.Exists(c.Request.Context(), userID, keyword); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "Keyword already blocked"})
+		return
+	}
+
+	blocked := models.BlockedKeyword{SellerID: userID, Keyword: keyword}
+	if err := h.repos.Keywords.Create(c.Request.Context(), &blocked); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add keyword"})
+		return
+	}
+	c.JSON(http.StatusCreated, blocked)
+}
+
+// @Summary Remove blocked keyword
+// @Description Stop holding comments containing a keyword
+// @Tags comments
+// @Produce json
+// @Param id path int true "Blocked keyword ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/blocked-keywords/{id} [delete]
+func (h *PostHandler) RemoveBlockedKeyword(c *gin.Context) {
+	keywordID, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Keyword not found"})
+		return
+	}
+	removed, err := h.repos.Keywords.Delete(c.Request.Context(), keywordID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove keyword"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Keyword not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Keyword removed"})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"instagram-backend/config"
+	"instagram-backend/contentfilter"
+	"instagram-backend/models"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// NewContentFilter builds the filter chain run on comments and captions: word
+// lists, link and duplicate heuristics, then sellers' own blocked keywords.
+func NewContentFilter(cfg config.ContentFilterConfig, db *gorm.DB) contentfilter.Chain {
+	wordLists, err := contentfilter.LoadWordLists(cfg.WordListDir, cfg.DefaultLocale)
+	if err != nil {
+		slog.Error("Failed to load content filter word lists, using built-in lists", "error", err)
+		wordLists, _ = contentfilter.LoadWordLists("", cfg.DefaultLocale)
+	}
+
+	return contentfilter.Chain{
+		wordLists,
+		contentfilter.LinkFilter{MaxLinks: cfg.MaxLinks},
+		contentfilter.DuplicateFilter{
+			MaxRepeats: cfg.MaxRepeats,
+			Window:     cfg.DuplicateWindow,
+			CountRecent: func(ctx context.Context, authorID uint, text string, since time.Time) (int64, error) {
+				var count int64
+				err := db.WithContext(ctx).Model(&models.Comment{}).
+					Where("user_id = ? AND content = ? AND created_at > ?", authorID, text, since).
+					Count(&count).Error
+				return count, err
+			},
+		},
+		contentfilter.KeywordFilter{
+			Keywords: func(ctx context.Context, sellerID uint) ([]string, error) {
+				var keywords []string
+				err := db.WithContext(ctx).Model(&models.BlockedKeyword{}).
+					Where("seller_id = ?", sellerID).Pluck("keyword", &keywords).Error
+				return keywords, err
+			},
+		},
+	}
+}
+
+// requestLocale is the first language in the Accept-Language header.
+func requestLocale(c *gin.Context) string {
+	locale, _, _ := strings.Cut(c.GetHeader("Accept-Language"), ",")
+	locale, _, _ = strings.Cut(locale, ";")
+	return strings.TrimSpace(locale)
+}
+
+// screenText runs the content filter. Filter failures are logged and the text
+// allowed, so an outage doesn't block all commenting.
+func (h *PostHandler) screenText(c *gin.Context, in *contentfilter.Input) contentfilter.Verdict {
+	if h.contentFilter == nil {
+		return contentfilter.Verdict{Action: contentfilter.Allow}
+	}
+	if in.Locale == "" {
+		in.Locale = requestLocale(c)
+	}
+	verdict, err := h.contentFilter.Check(c.Request.Context(), in)
+	if err != nil {
+		requestLogger(c).Error("Content filter failed", "error", err)
+		return contentfilter.Verdict{Action: contentfilter.Allow}
+	}
+	return verdict
+}
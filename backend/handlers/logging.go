@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"instagram-backend/logging"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestLogger returns the request's logger, tagged with its request ID.
+func requestLogger(c *gin.Context) *slog.Logger {
+	return logging.FromContext(c.Request.Context())
+}
@@ -0,0 +1,683 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"instagram-backend/audit"
+	"instagram-backend/cache"
+	"instagram-backend/config"
+	"instagram-backend/logging"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Moderator actions, also recorded on the notices sent to users.
+const (
+	actionDismiss       = "dismiss"
+	actionRemoveContent = "remove_content"
+	actionShadowHide    = "shadow_hide"
+	actionWarn          = "warn"
+	actionSuspend       = "suspend"
+	// actionAutoHide is taken by the system once reports cross the threshold.
+	actionAutoHide = "auto_hide"
+)
+
+var errReportTargetNotFound = errors.New("Reported content not found")
+
+type ModerationHandler struct {
+	repos             *repository.Repositories
+	cache             *cache.Cache
+	autoHideThreshold int64
+}
+
+func NewModerationHandler(cfg *config.Config, repos *repository.Repositories, cache *cache.Cache) *ModerationHandler {
+	return &ModerationHandler{repos: repos, cache: cache, autoHideThreshold: cfg.Moderation.ReportAutoHideThreshold}
+}
+
+type CreateReportRequest struct {
+	TargetType string `json:"targetType" binding:"required,oneof=post comment user"`
+	TargetID   uint   `json:"targetId" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+	Details    string `json:"details" binding:"max=1000"`
+}
+
+type ResolveReportRequest struct {
+	Action      string `json:"action" binding:"required,oneof=dismiss remove_content shadow_hide warn suspend"`
+	SuspendDays int    `json:"suspendDays"`
+	Message     string `json:"message"`
+}
+
+// reportTargetOwner returns the user responsible for a reported post, comment
+// or account.
+func reportTargetOwner(ctx context.Context, repos *repository.Repositories, targetType string, targetID uint) (uint, error) {
+	var ownerID uint
+	var err error
+	switch targetType {
+	case models.ReportTargetPost:
+		var post *models.Post
+		if post, err = repos.Posts.FindByID(ctx, targetID); err == nil {
+			ownerID = post.UserID
+		}
+	case models.ReportTargetComment:
+		var comment *models.Comment
+		if comment, err = repos.Comments.FindByID(ctx, targetID); err == nil {
+			ownerID = comment.UserID
+		}
+	case models.ReportTargetUser:
+		var user *models.User
+		if user, err = repos.Users.FindByID(ctx, targetID); err == nil {
+			ownerID = user.ID
+		}
+	default:
+		return 0, errReportTargetNotFound
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, errReportTargetNotFound
+	}
+	return ownerID, err
+}
+
+// moderationStatus returns the moderation status of a reported post or
+// comment. Accounts have no moderation status, so it is always empty for them.
+func moderationStatus(ctx context.Context, repos *repository.Repositories, targetType string, targetID uint) (string, error) {
+	switch targetType {
+	case models.ReportTargetPost:
+		post, err := repos.Posts.FindByID(ctx, targetID)
+		if err != nil {
+			return "", err
+		}
+		return post.ModerationStatus, nil
+	case models.ReportTargetComment:
+		comment, err := repos.Comments.FindByID(ctx, targetID)
+		if err != nil {
+			return "", err
+		}
+		return comment.ModerationStatus, nil
+	}
+	return "", nil
+}
+
+// setModerationStatus changes the moderation status of a reported post or
+// comment and returns the post whose cached copy is now stale. Accounts have
+// no moderation status, so it does nothing for them and returns zero.
+func setModerationStatus(ctx context.Context, repos *repository.Repositories, targetType string, targetID uint, status string) (uint, error) {
+	switch targetType {
+	case models.ReportTargetPost:
+		if err := repos.Posts.SetModerationStatus(ctx, targetID, status); err != nil {
+			return 0, err
+		}
+		return targetID, nil
+	case models.ReportTargetComment:
+		comment, err := repos.Comments.FindByID(ctx, targetID)
+		if err != nil {
+			return 0, err
+		}
+		if err := repos.Comments.SetModerationStatus(ctx, comment.ID, status); err != nil {
+			return 0, err
+		}
+		// The post embeds its comments in the cache
+		return comment.PostID, nil
+	}
+	return 0, nil
+}
+
+// invalidatePost drops a post changed by setModerationStatus from the cache.
+// Call it once the change is committed, or a concurrent read could cache the
+// old status again.
+func (h *ModerationHandler) invalidatePost(ctx context.Context, postID uint) {
+	if postID == 0 {
+		return
+	}
+	if err := h.cache.InvalidatePostCache(ctx, postID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to invalidate post cache", "post_id", postID, "error", err)
+	}
+}
+
+// @Summary Report content
+// @Description Report a post, comment or account. Content is hidden automatically once enough users report it.
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param report body CreateReportRequest true "Report"
+// @Success 201 {object} models.Report
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/reports [post]
+func (h *ModerationHandler) CreateReport(c *gin.Context) {
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validReason := false
+	for _, reason := range models.ReportReasons {
+		validReason = validReason || reason == req.Reason
+	}
+	if !validReason {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown reason", "reasons": models.ReportReasons})
+		return
+	}
+
+	ctx := c.Request.Context()
+	reporterID := c.GetUint("user_id")
+	ownerID, err := reportTargetOwner(ctx, h.repos, req.TargetType, req.TargetID)
+	if errors.Is(err, errReportTargetNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		return
+	}
+
+	if ownerID == reporterID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own content"})
+		return
+	}
+
+	if exists, _ := h.repos.Reports.Exists(ctx, reporterID, req.TargetType, req.TargetID); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this"})
+		return
+	}
+
+	report := models.Report{
+		ReporterID: reporterID,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     models.ReportStatusOpen,
+	}
+	if err := h.repos.Reports.Create(ctx, &report); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		return
+	}
+
+	if err := h.autoHide(ctx, report.TargetType, report.TargetID, ownerID); err != nil {
+		requestLogger(c).Error("Failed to auto-hide reported content", "target_type", report.TargetType, "target_id", report.TargetID, "error", err)
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// autoHide temporarily hides a post or comment once its unresolved reports
+// reach the threshold, until a moderator reviews it.
+func (h *ModerationHandler) autoHide(ctx context.Context, targetType string, targetID, ownerID uint) error {
+	if targetType == models.ReportTargetUser {
+		return nil
+	}
+
+	unresolved, err := h.repos.Reports.CountUnresolved(ctx, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if unresolved < h.autoHideThreshold {
+		return nil
+	}
+
+	status, err := moderationStatus(ctx, h.repos, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		return nil
+	}
+
+	var staleID uint
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		var err error
+		if staleID, err = setModerationStatus(ctx, tx, targetType, targetID, models.ModerationHidden); err != nil {
+			return err
+		}
+		return tx.Notices.Create(ctx, &models.Notice{
+			UserID:     ownerID,
+			TargetType: targetType,
+			TargetID:   targetID,
+			Action:     actionAutoHide,
+			Message:    fmt.Sprintf("Your %s has been hidden while we review reports about it.", targetType),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	h.invalidatePost(ctx, staleID)
+	return nil
+}
+
+// @Summary List reports
+// @Description Moderation queue, oldest first. Defaults to unresolved reports.
+// @Tags admin
+// @Produce json
+// @Param status query string false "open, assigned or resolved"
+// @Param targetType query string false "post, comment or user"
+// @Param assignee query string false "\"me\" for reports assigned to the current moderator"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {array} models.Report
+// @Security BearerAuth
+// @Router /api/v1/admin/reports [get]
+func (h *ModerationHandler) ListReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	filter := repository.ReportFilter{Status: c.Query("status"), TargetType: c.Query("targetType")}
+	if c.Query("assignee") == "me" {
+		filter.AssigneeID = c.GetUint("user_id")
+	}
+
+	reports, err := h.repos.Reports.List(c.Request.Context(), filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// @Summary Assign report
+// @Description Assign a report to a moderator, by default the current one
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Report ID"
+// @Param assignee body map[string]uint false "Assignee, e.g. {\"assigneeId\": 3}"
+// @Success 200 {object} models.Report
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/reports/{id}/assign [post]
+func (h *ModerationHandler) AssignReport(c *gin.Context) {
+	var req struct {
+		AssigneeID uint `json:"assigneeId"`
+	}
+	// The body is optional
+	_ = c.ShouldBindJSON(&req)
+	if req.AssigneeID == 0 {
+		req.AssigneeID = c.GetUint("user_id")
+	}
+
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	ctx := c.Request.Context()
+	report, err := h.repos.Reports.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	if report.Status == models.ReportStatusResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report is already resolved"})
+		return
+	}
+
+	assignee, err := h.repos.Users.FindByID(ctx, req.AssigneeID)
+	if err != nil || assignee.Role != "admin" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Assignee must be an admin"})
+		return
+	}
+
+	if err := h.repos.Reports.Assign(ctx, report, assignee.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign report"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{
+		Action:     audit.ActionReportAssign,
+		TargetType: audit.TargetReport,
+		TargetID:   report.ID,
+		Details:    map[string]interface{}{"assigneeId": assignee.ID},
+	})
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Resolve report
+// @Description Act on a report. The action applies to every unresolved report about the same target, and the owner gets a notice they can appeal.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Report ID"
+// @Param resolution body ResolveReportRequest true "Action to take"
+// @Success 200 {object} models.Report
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/reports/{id}/resolve [post]
+func (h *ModerationHandler) ResolveReport(c *gin.Context) {
+	var req ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	ctx := c.Request.Context()
+	report, err := h.repos.Reports.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	if report.Status == models.ReportStatusResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report is already resolved"})
+		return
+	}
+
+	if report.TargetType == models.ReportTargetUser && (req.Action == actionRemoveContent || req.Action == actionShadowHide) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Accounts can only be warned or suspended"})
+		return
+	}
+
+	ownerID, err := reportTargetOwner(ctx, h.repos, report.TargetType, report.TargetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	moderatorID := c.GetUint("user_id")
+	now := time.Now()
+	var staleID uint
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		message := req.Message
+		var err error
+		switch req.Action {
+		case actionRemoveContent:
+			if staleID, err = setModerationStatus(ctx, tx, report.TargetType, report.TargetID, models.ModerationRemoved); err != nil {
+				return err
+			}
+			if message == "" {
+				message = fmt.Sprintf("Your %s was removed for violating our guidelines (%s).", report.TargetType, report.Reason)
+			}
+		case actionShadowHide:
+			if staleID, err = setModerationStatus(ctx, tx, report.TargetType, report.TargetID, models.ModerationShadowHidden); err != nil {
+				return err
+			}
+			if message == "" {
+				message = fmt.Sprintf("Your %s has limited visibility for violating our guidelines (%s).", report.TargetType, report.Reason)
+			}
+		case actionWarn:
+			if err := tx.Users.AddWarnings(ctx, ownerID, 1); err != nil {
+				return err
+			}
+			if message == "" {
+				message = fmt.Sprintf("You received a warning for violating our guidelines (%s).", report.Reason)
+			}
+		case actionSuspend:
+			days := req.SuspendDays
+			if days <= 0 {
+				days = 7 // Default value
+			}
+			until := now.Add(time.Duration(days) * 24 * time.Hour)
+			if err := tx.Users.Suspend(ctx, ownerID, &until); err != nil {
+				return err
+			}
+			if message == "" {
+				message = fmt.Sprintf("Your account is suspended until %s for violating our guidelines (%s).",
+					until.Format(time.RFC1123), report.Reason)
+			}
+		}
+
+		if err := tx.Reports.ResolveAll(ctx, report.TargetType, report.TargetID, req.Action, moderatorID, now); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionReportResolve,
+			TargetType: audit.TargetReport,
+			TargetID:   report.ID,
+			Details: map[string]interface{}{
+				"action":     req.Action,
+				"targetType": report.TargetType,
+				"targetId":   report.TargetID,
+				"ownerId":    ownerID,
+			},
+		}); err != nil {
+			return err
+		}
+
+		// Dismissals need no notice
+		if req.Action == actionDismiss {
+			return nil
+		}
+		return tx.Notices.Create(ctx, &models.Notice{
+			UserID:     ownerID,
+			ReportID:   &report.ID,
+			TargetType: report.TargetType,
+			TargetID:   report.TargetID,
+			Action:     req.Action,
+			Message:    message,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report"})
+		return
+	}
+	h.invalidatePost(ctx, staleID)
+
+	// A dismissal restores content that was only hidden automatically
+	if req.Action == actionDismiss {
+		if err := h.restoreAutoHidden(ctx, report.TargetType, report.TargetID); err != nil {
+			requestLogger(c).Error("Failed to restore reported content", "target_type", report.TargetType, "target_id", report.TargetID, "error", err)
+		}
+	}
+
+	if err := h.cache.InvalidateUserCache(ctx, ownerID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", ownerID, "error", err)
+	}
+
+	if resolved, err := h.repos.Reports.FindByID(ctx, report.ID); err == nil {
+		report = resolved
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *ModerationHandler) restoreAutoHidden(ctx context.Context, targetType string, targetID uint) error {
+	if targetType == models.ReportTargetUser {
+		return nil
+	}
+	status, err := moderationStatus(ctx, h.repos, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if status != models.ModerationHidden {
+		return nil
+	}
+	staleID, err := setModerationStatus(ctx, h.repos, targetType, targetID, "")
+	if err != nil {
+		return err
+	}
+	h.invalidatePost(ctx, staleID)
+	return nil
+}
+
+// @Summary List appeals
+// @Description List notices whose recipients have appealed and are awaiting review
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Notice
+// @Security BearerAuth
+// @Router /api/v1/admin/appeals [get]
+func (h *ModerationHandler) ListAppeals(c *gin.Context) {
+	notices, err := h.repos.Notices.ListAppealed(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch appeals"})
+		return
+	}
+	c.JSON(http.StatusOK, notices)
+}
+
+// @Summary Resolve appeal
+// @Description Uphold or overturn the action behind an appealed notice. Overturning reverses it.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Notice ID"
+// @Param decision body map[string]bool true "Decision, e.g. {\"overturn\": true}"
+// @Success 200 {object} models.Notice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/appeals/{id}/resolve [post]
+func (h *ModerationHandler) ResolveAppeal(c *gin.Context) {
+	var req struct {
+		Overturn *bool `json:"overturn" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appeal not found"})
+		return
+	}
+	ctx := c.Request.Context()
+	notice, err := h.repos.Notices.FindAppealed(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appeal not found"})
+		return
+	}
+
+	status := models.AppealUpheld
+	if *req.Overturn {
+		status = models.AppealOverturned
+	}
+
+	var staleID uint
+	notice.AppealStatus = status
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if *req.Overturn {
+			switch notice.Action {
+			case actionRemoveContent, actionShadowHide, actionAutoHide:
+				var err error
+				if staleID, err = setModerationStatus(ctx, tx, notice.TargetType, notice.TargetID, ""); err != nil {
+					return err
+				}
+			case actionWarn:
+				if err := tx.Users.AddWarnings(ctx, notice.UserID, -1); err != nil {
+					return err
+				}
+			case actionSuspend:
+				if err := tx.Users.Suspend(ctx, notice.UserID, nil); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Notices.Update(ctx, notice, "AppealStatus"); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionAppealResolve,
+			TargetType: audit.TargetNotice,
+			TargetID:   notice.ID,
+			Details:    map[string]interface{}{"status": status, "action": notice.Action, "userId": notice.UserID},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve appeal"})
+		return
+	}
+	h.invalidatePost(ctx, staleID)
+
+	if err := h.cache.InvalidateUserCache(ctx, notice.UserID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", notice.UserID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, notice)
+}
+
+// @Summary List notices
+// @Description List moderation notices sent to the current user
+// @Tags moderation
+// @Produce json
+// @Success 200 {array} models.Notice
+// @Security BearerAuth
+// @Router /api/v1/me/notices [get]
+func (h *ModerationHandler) ListNotices(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	notices, err := h.repos.Notices.List(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notices"})
+		return
+	}
+
+	if err := h.repos.Notices.MarkRead(ctx, userID); err != nil {
+		requestLogger(c).Warn("Failed to mark notices read", "user_id", userID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, notices)
+}
+
+// @Summary Appeal notice
+// @Description Ask moderators to review an action taken against the current user
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path int true "Notice ID"
+// @Param appeal body map[string]string true "Appeal, e.g. {\"text\": \"...\"}"
+// @Success 200 {object} models.Notice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/notices/{id}/appeal [post]
+func (h *ModerationHandler) AppealNotice(c *gin.Context) {
+	var req struct {
+		Text string `json:"text" binding:"required,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notice not found"})
+		return
+	}
+	notice, err := h.repos.Notices.Find(c.Request.Context(), id, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notice not found"})
+		return
+	}
+
+	if notice.AppealStatus != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "This notice has already been appealed"})
+		return
+	}
+
+	now := time.Now()
+	notice.AppealText = req.Text
+	notice.AppealStatus = models.AppealPending
+	notice.AppealedAt = &now
+	if err := h.repos.Notices.Update(c.Request.Context(), notice, "AppealText", "AppealStatus", "AppealedAt"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit appeal"})
+		return
+	}
+
+	c.JSON(http.StatusOK, notice)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"instagram-backend/config"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var pageTemplates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// profilePagePosts is how many recent posts a profile page shows.
+const profilePagePosts = 12
+
+// PageHandler renders the server-side HTML pages that shared links point to,
+// with Open Graph tags so chat apps and social networks can build previews.
+type PageHandler struct {
+	repos   *repository.Repositories
+	baseURL string
+}
+
+func NewPageHandler(cfg *config.Config, repos *repository.Repositories) *PageHandler {
+	return &PageHandler{repos: repos, baseURL: strings.TrimSuffix(cfg.Server.BaseURL, "/")}
+}
+
+// pageMeta fills the Open Graph and Twitter card tags in templates/layout.html.
+type pageMeta struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	Type        string
+}
+
+// ProfilePage renders /u/*username.
+func (h *PageHandler) ProfilePage(c *gin.Context) {
+	user, redirected, err := h.repos.Users.FindByUsername(c.Request.Context(), usernameParam(c))
+	if errors.Is(err, repository.ErrNotFound) {
+		h.render(c, http.StatusNotFound, "not_found.html", nil)
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+	if redirected {
+		c.Redirect(http.StatusMovedPermanently, usernamePath("/u/", user.Username))
+		return
+	}
+
+	profile, err := loadPublicProfile(c.Request.Context(), h.repos, user)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	// Private accounts show their profile but not their posts
+	var posts []models.Post
+	if !user.IsPrivate {
+		if posts, err = h.repos.Posts.ListPublished(c.Request.Context(), user.ID, profilePagePosts); err != nil {
+			requestLogger(c).Error("Failed to fetch posts for profile page", "user_id", user.ID, "error", err)
+		}
+	}
+
+	title := profile.Name + " (@" + profile.Username + ")"
+	description := profile.Bio
+	if description == "" {
+		description = "See posts from " + profile.Name + " on Instagram."
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	h.render(c, http.StatusOK, "profile.html", gin.H{
+		"BaseURL": h.baseURL,
+		"Profile": profile,
+		"Posts":   posts,
+		"Meta": pageMeta{
+			Title:       title,
+			Description: truncate(description, 200),
+			URL:         h.baseURL + usernamePath("/u/", profile.Username),
+			Image:       profile.ProfileImage,
+			Type:        "profile",
+		},
+	})
+}
+
+// PostPage renders /p/:id.
+func (h *PageHandler) PostPage(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		h.render(c, http.StatusNotFound, "not_found.html", nil)
+		return
+	}
+
+	post, err := h.repos.Posts.FindWithMedia(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (post.User.IsPrivate || post.ModerationStatus != "")) {
+		h.render(c, http.StatusNotFound, "not_found.html", nil)
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Something went wrong")
+		return
+	}
+
+	meta := pageMeta{
+		Title:       post.User.Name + " on Instagram",
+		Description: truncate(post.Caption, 200),
+		URL:         h.baseURL + c.Request.URL.Path,
+		Type:        "article",
+	}
+	if meta.Description == "" {
+		meta.Description = "See this post by @" + post.User.Username + " on Instagram."
+	}
+	if len(post.PostImages) > 0 {
+		meta.Image = post.PostImages[0].ImageURL
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	h.render(c, http.StatusOK, "post.html", gin.H{
+		"BaseURL": h.baseURL,
+		"Post":    post,
+		"Meta":    meta,
+	})
+}
+
+func (h *PageHandler) render(c *gin.Context, status int, name string, data interface{}) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
+		requestLogger(c).Error("Failed to render page", "template", name, "error", err)
+	}
+}
+
+// truncate shortens s to at most n runes, adding an ellipsis when cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idParam parses the :id path parameter. Handlers must pass IDs to GORM as
+// numbers: First and Find treat a string argument as a raw SQL condition.
+func idParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
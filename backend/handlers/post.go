@@ -1,25 +1,36 @@
 package handlers
 
 import (
-	"os"
-	"strconv"
+	"instagram-backend/cache"
+	"instagram-backend/config"
+	"instagram-backend/contentfilter"
+	"instagram-backend/metrics"
+	"instagram-backend/repository"
+	"instagram-backend/service"
 	"time"
-
-	"gorm.io/gorm"
 )
 
 type PostHandler struct {
-	db        *gorm.DB
-	rateLimit *time.Ticker
+	repos           *repository.Repositories
+	posts           *service.PostService
+	cache           *cache.Cache
+	metrics         *metrics.Metrics
+	rateLimit       *time.Ticker
+	publishPolicies []PublishPolicy
+	contentFilter   contentfilter.Filter
+	// trashRetention is how long deleted posts and comments can be restored.
+	trashRetention time.Duration
 }
 
-func NewPostHandler(db *gorm.DB) *PostHandler {
-	requestsPerSecond, _ := strconv.Atoi(os.Getenv("RATE_LIMIT_REQUESTS_PER_SECOND"))
-	if requestsPerSecond <= 0 {
-		requestsPerSecond = 10 // Default value
-	}
+func NewPostHandler(cfg *config.Config, repos *repository.Repositories, services *service.Services, cache *cache.Cache, metrics *metrics.Metrics, filter contentfilter.Filter, policies ...PublishPolicy) *PostHandler {
 	return &PostHandler{
-		db:        db,
-		rateLimit: time.NewTicker(time.Second / time.Duration(requestsPerSecond)),
+		repos:           repos,
+		posts:           services.Posts,
+		cache:           cache,
+		metrics:         metrics,
+		rateLimit:       time.NewTicker(time.Second / time.Duration(cfg.Server.RequestsPerSecond)),
+		publishPolicies: policies,
+		contentFilter:   filter,
+		trashRetention:  cfg.Retention.Trash,
 	}
 }
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"instagram-backend/audit"
+	"instagram-backend/contentfilter"
 	"instagram-backend/models"
+	"instagram-backend/repository"
+	"instagram-backend/service"
 	"net/http"
 	"strconv"
 
@@ -12,6 +17,11 @@ func (h *PostHandler) CreateComment(c *gin.Context) {
 	postID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userID := c.GetUint("user_id")
 
+	post, ok := h.findVisiblePost(c, uint(postID))
+	if !ok {
+		return
+	}
+
 	var req struct {
 		Content string `json:"content" binding:"required"`
 	}
@@ -27,23 +37,43 @@ func (h *PostHandler) CreateComment(c *gin.Context) {
 		UserID:  userID,
 	}
 
-	if result := h.db.Create(&comment); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+	// Comments the filter objects to are held for the seller to review
+	verdict := h.screenText(c, &contentfilter.Input{
+		Kind:     contentfilter.KindComment,
+		Text:     req.Content,
+		AuthorID: userID,
+		SellerID: post.UserID,
+	})
+	switch verdict.Action {
+	case contentfilter.Reject:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Comment " + verdict.Reason})
 		return
+	case contentfilter.Hold:
+		comment.Status = models.CommentHeld
+		comment.FilterReason = verdict.Reason
 	}
 
-	// Load the user data for the response.
-	h.db.Preload("User").First(&comment, comment.ID)
+	if err := h.posts.AddComment(c.Request.Context(), &comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
 
+	if comment.Status == models.CommentHeld {
+		c.JSON(http.StatusAccepted, comment)
+		return
+	}
 	c.JSON(http.StatusCreated, comment)
 }
 
 func (h *PostHandler) GetComments(c *gin.Context) {
-	postID := c.Param("id")
+	postID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 
-	// Get comments with user information
-	var comments []models.Comment
-	if err := h.db.Preload("User").Where("post_id = ?", postID).Find(&comments).Error; err != nil {
+	comments, err := h.posts.Comments(c.Request.Context(), c.GetUint("user_id"), uint(postID))
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
 		return
 	}
@@ -53,11 +83,15 @@ func (h *PostHandler) GetComments(c *gin.Context) {
 
 func (h *PostHandler) DeleteComment(c *gin.Context) {
 	userID := c.GetUint("user_id")
-	commentID := c.Param("commentId")
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
 
 	// Find the comment
-	var comment models.Comment
-	if err := h.db.First(&comment, commentID).Error; err != nil {
+	comment, err := h.repos.Comments.FindByID(c.Request.Context(), uint(commentID))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 		return
 	}
@@ -69,26 +103,22 @@ func (h *PostHandler) DeleteComment(c *gin.Context) {
 	}
 
 	// Delete the comment
-	if err := h.db.Delete(&comment).Error; err != nil {
+	ctx := c.Request.Context()
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Comments.Trash(ctx, comment.ID, userID); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionCommentDelete,
+			TargetType: audit.TargetComment,
+			TargetID:   comment.ID,
+			Details:    map[string]interface{}{"postId": comment.PostID},
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
-
-func (h *PostHandler) GetPostComments(c *gin.Context) {
-	postID := c.Param("id")
-	var comments []models.Comment
-	result := h.db.Preload("User").
-		Where("post_id = ?", postID).
-		Order("created_at desc").
-		Find(&comments)
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
-		return
-	}
-
-	c.JSON(http.StatusOK, comments)
-}
\ No newline at end of file
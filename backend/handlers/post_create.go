@@ -1,8 +1,10 @@
 package handlers
 
 import (
-	"net/http"
+	"instagram-backend/contentfilter"
+	"instagram-backend/middleware"
 	"instagram-backend/models"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,6 +32,8 @@ type CreatePostRequest struct {
 // @Param post body CreatePostRequest true "Post creation information"
 // @Success 201 {object} models.SwaggerPost
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string "Caption rejected by the content filter"
 // @Failure 500 {object} map[string]string
 // @Security BearerAuth
 // @Router /api/posts [post]
@@ -42,6 +46,29 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 
 	userID := c.GetUint("user_id")
 
+	// Purchase links are product data, which API keys need a separate scope for.
+	if len(req.PurchaseOptions) > 0 && !middleware.HasScope(c, models.ScopeProductsWrite) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + models.ScopeProductsWrite + " scope"})
+		return
+	}
+
+	author, err := h.repos.Users.FindByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.checkPublishPolicies(c.Request.Context(), author, &req); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	verdict := h.screenText(c, &contentfilter.Input{Kind: contentfilter.KindCaption, Text: req.Caption, AuthorID: userID})
+	if verdict.Action == contentfilter.Reject {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Caption " + verdict.Reason})
+		return
+	}
+
 	// Create the post object with common fields.
 	post := models.Post{
 		Caption:     req.Caption,
@@ -53,7 +80,10 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 	// Depending on the content type, assign the relevant field.
 	switch req.ContentType {
 	case "feed":
-		// For feed posts, images will be attached after post creation.
+		// For feed posts, images are saved along with the post.
+		for _, url := range req.ImageURLs {
+			post.PostImages = append(post.PostImages, models.PostImage{ImageURL: url})
+		}
 	case "reel":
 		post.VideoURL = req.VideoURL
 	case "live":
@@ -63,40 +93,27 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
+	// Attach purchase options if provided.
+	for _, po := range req.PurchaseOptions {
+		post.PurchaseOptions = append(post.PurchaseOptions, models.PurchaseOption{
+			Platform: po.Platform,
+			URL:      po.URL,
+		})
+	}
+
 	// Create the post record.
-	if result := h.db.Create(&post); result.Error != nil {
+	if err := h.repos.Posts.Create(c.Request.Context(), &post); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
-
-	// For feed posts, create PostImage records.
-	if req.ContentType == "feed" && len(req.ImageURLs) > 0 {
-		for _, url := range req.ImageURLs {
-			postImage := models.PostImage{
-				PostID:   post.ID,
-				ImageURL: url,
-			}
-			h.db.Create(&postImage)
-		}
-	}
-
-	// Create purchase options if provided.
-	if len(req.PurchaseOptions) > 0 {
-		for _, po := range req.PurchaseOptions {
-			purchaseOption := models.PurchaseOption{
-				PostID:   post.ID,
-				Platform: po.Platform,
-				URL:      po.URL,
-			}
-			h.db.Create(&purchaseOption)
-		}
-	}
+	h.metrics.PostCreated()
 
 	// Load the post with associations for the response.
-	h.db.Preload("User").
-		Preload("PostImages").
-		Preload("PurchaseOptions").
-		First(&post, post.ID)
+	created, err := h.repos.Posts.FindWithMedia(c.Request.Context(), post.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load post"})
+		return
+	}
 
-	c.JSON(http.StatusCreated, post)
-}
\ No newline at end of file
+	c.JSON(http.StatusCreated, created)
+}
@@ -1,9 +1,10 @@
 package handlers
 
 import (
-	"instagram-backend/cache"
+	"instagram-backend/audit"
+	"instagram-backend/middleware"
 	"instagram-backend/models"
-	"log"
+	"instagram-backend/repository"
 	"net/http"
 	"strconv"
 
@@ -11,17 +12,20 @@ import (
 )
 
 func (h *PostHandler) DeletePost(c *gin.Context) {
-	id := c.Param("id")
 	userID := c.GetUint("user_id")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
 
 	// Invalidate the post cache before deletion
-	postID, _ := strconv.ParseUint(id, 10, 32)
-	if err := cache.InvalidatePostCache(c.Request.Context(), uint(postID)); err != nil {
-		log.Printf("Failed to invalidate post cache: %v", err)
+	if err := h.cache.InvalidatePostCache(c.Request.Context(), uint(postID)); err != nil {
+		requestLogger(c).Warn("Failed to invalidate post cache", "post_id", postID, "error", err)
 	}
 
-	var post models.Post
-	if result := h.db.First(&post, id); result.Error != nil {
+	post, err := h.repos.Posts.FindWithMedia(c.Request.Context(), uint(postID))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
@@ -31,7 +35,23 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	if result := h.db.Delete(&post); result.Error != nil {
+	// Deleting a post deletes its purchase links too, which API keys need the
+	// product scope for, as when creating them.
+	if len(post.PurchaseOptions) > 0 && !middleware.HasScope(c, models.ScopeProductsWrite) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + models.ScopeProductsWrite + " scope"})
+		return
+	}
+
+	// The post goes to the trash with its images, purchase options, likes and
+	// comments, and can be restored from there for a while
+	ctx := c.Request.Context()
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Posts.Trash(ctx, post.ID, userID); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{Action: audit.ActionPostDelete, TargetType: audit.TargetPost, TargetID: post.ID})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
 		return
 	}
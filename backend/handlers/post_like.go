@@ -1,7 +1,8 @@
 package handlers
 
 import (
-	"instagram-backend/models"
+	"errors"
+	"instagram-backend/service"
 	"net/http"
 	"strconv"
 
@@ -12,22 +13,20 @@ func (h *PostHandler) LikePost(c *gin.Context) {
 	postID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userID := c.GetUint("user_id")
 
-	var existingLike models.Like
-	result := h.db.Where("post_id = ? AND user_id = ?", postID, userID).First(&existingLike)
-	if result.Error == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Post already liked"})
+	err := h.posts.Like(c.Request.Context(), userID, uint(postID))
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
-
-	like := models.Like{
-		PostID: uint(postID),
-		UserID: userID,
+	if errors.Is(err, service.ErrAlreadyLiked) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	if result := h.db.Create(&like); result.Error != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like post"})
 		return
 	}
+	h.metrics.Liked()
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Post liked successfully"})
 }
@@ -36,14 +35,13 @@ func (h *PostHandler) UnlikePost(c *gin.Context) {
 	postID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	userID := c.GetUint("user_id")
 
-	result := h.db.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&models.Like{})
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlike post"})
+	err := h.posts.Unlike(c.Request.Context(), userID, uint(postID))
+	if errors.Is(err, service.ErrNotLiked) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Post not liked"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlike post"})
 		return
 	}
 
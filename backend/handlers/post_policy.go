@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+)
+
+// PublishPolicy decides whether author may publish the requested post. A
+// non-nil error refuses the request and its message is returned to the client.
+type PublishPolicy func(ctx context.Context, author *models.User, req *CreatePostRequest) error
+
+var errUnverifiedSeller = errors.New("Verify your email address before publishing posts with purchase options")
+
+// RequireVerifiedSellerForPurchaseOptions stops sellers who have not verified
+// their email address from attaching purchase links to posts.
+func RequireVerifiedSellerForPurchaseOptions(ctx context.Context, author *models.User, req *CreatePostRequest) error {
+	if author.Role == "seller" && len(req.PurchaseOptions) > 0 && !author.EmailVerified {
+		return errUnverifiedSeller
+	}
+	return nil
+}
+
+// checkPublishPolicies runs every configured policy and returns the first refusal.
+func (h *PostHandler) checkPublishPolicies(ctx context.Context, author *models.User, req *CreatePostRequest) error {
+	for _, policy := range h.publishPolicies {
+		if err := policy(ctx, author, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errSellerTwoFactorRequired = errors.New("Enable two-factor authentication before publishing posts")
+
+// RequireSellerTwoFactor refuses posts from sellers without TOTP while the
+// admin "2FA required for sellers" setting is on.
+func RequireSellerTwoFactor(settings repository.SettingRepository) PublishPolicy {
+	return func(ctx context.Context, author *models.User, req *CreatePostRequest) error {
+		if author.Role == "seller" && !author.TOTPEnabled && settingEnabled(ctx, settings, models.SettingRequireSellerTwoFactor) {
+			return errSellerTwoFactorRequired
+		}
+		return nil
+	}
+}
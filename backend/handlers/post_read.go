@@ -1,18 +1,14 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"instagram-backend/cache"
-	"instagram-backend/config"
-	"instagram-backend/models"
-	"log"
+	"instagram-backend/service"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // @Summary Get all posts
@@ -36,12 +32,11 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 	// Add pagination
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
-	offset := (page - 1) * pageSize
 
-	// Try to get posts from cache first
-	cacheKey := fmt.Sprintf("posts:page:%d:size:%d", page, pageSize)
-	redisClient := config.GetRedisClient()
-	cachedData, err := redisClient.Get(context.Background(), cacheKey).Bytes()
+	// Private accounts make the feed depend on who is asking, so cache per viewer
+	viewerID := c.GetUint("user_id")
+	cacheKey := fmt.Sprintf("posts:page:%d:size:%d:viewer:%d", page, pageSize, viewerID)
+	cachedData, err := h.cache.GetCachedPage(c.Request.Context(), cacheKey)
 	if err == nil {
 		var response gin.H
 		if err := json.Unmarshal(cachedData, &response); err == nil {
@@ -52,113 +47,65 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 		}
 	}
 
-	// Use parallel processing for counting total posts
-	totalChan := make(chan int64)
-	postsChan := make(chan []models.Post)
-	errorChan := make(chan error)
-
-	// Count total posts in parallel
-	go func() {
-		var total int64
-		if err := h.db.Model(&models.Post{}).Count(&total).Error; err != nil {
-			errorChan <- err
-			return
-		}
-		totalChan <- total
-	}()
-
-	// Fetch posts with pagination in parallel
-	go func() {
-		var posts []models.Post
-		result := h.db.Preload("User").
-			Preload("Likes").
-			Preload("Comments").
-			Preload("PostImages").
-			Preload("PurchaseOptions").
-			Order("created_at desc").
-			Offset(offset).Limit(pageSize).
-			Find(&posts)
-
-		if result.Error != nil {
-			errorChan <- result.Error
-			return
-		}
-		postsChan <- posts
-	}()
-
-	// Wait for results
-	select {
-	case err := <-errorChan:
+	posts, total, err := h.posts.Feed(c.Request.Context(), viewerID, page, pageSize)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts: " + err.Error()})
 		return
-	case total := <-totalChan:
-		posts := <-postsChan
-		// Set cache headers
-		c.Header("Cache-Control", "private, max-age=300")
-		c.JSON(http.StatusOK, gin.H{
-			"posts":      posts,
-			"total":      total,
-			"page":       page,
-			"pageSize":   pageSize,
-			"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
-		})
 	}
+
+	// Set cache headers
+	c.Header("Cache-Control", "private, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"posts":      posts,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
 }
 
 func (h *PostHandler) GetPost(c *gin.Context) {
 	// Apply rate limiting
 	<-h.rateLimit.C
 
-	id := c.Param("id")
-	postID, _ := strconv.ParseUint(id, 10, 32)
+	postID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	viewerID := c.GetUint("user_id")
 
 	// Try to get post from cache first
-	cachedPost, err := cache.GetCachedPost(c.Request.Context(), uint(postID))
+	cachedPost, err := h.cache.GetCachedPost(c.Request.Context(), uint(postID))
 	if err == nil {
+		if err := h.posts.ShowTo(c.Request.Context(), viewerID, cachedPost); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
 		c.Header("X-Cache", "HIT")
 		c.Header("Cache-Control", "private, max-age=300")
 		c.JSON(http.StatusOK, cachedPost)
 		return
 	}
 
-	var post models.Post
-	// Use parallel processing for fetching post and its associations
-	postChan := make(chan *models.Post)
-	errorChan := make(chan error)
-
-	go func() {
-		result := h.db.Preload("User").
-			Preload("Likes").
-			Preload("Comments.User").
-			Preload("PostImages").
-			Preload("PurchaseOptions").
-			First(&post, id)
-
-		if result.Error != nil {
-			errorChan <- result.Error
-			return
-		}
-
-		// Cache the post for future requests
-		if err := cache.CachePost(c.Request.Context(), &post); err != nil {
-			log.Printf("Failed to cache post: %v", err)
-		}
+	post, err := h.posts.Details(c.Request.Context(), uint(postID))
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post: " + err.Error()})
+		return
+	}
 
-		postChan <- &post
-	}()
+	// Cache the post for future requests
+	if err := h.cache.CachePost(c.Request.Context(), post); err != nil {
+		requestLogger(c).Warn("Failed to cache post", "post_id", post.ID, "error", err)
+	}
 
-	// Wait for results
-	select {
-	case err := <-errorChan:
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post: " + err.Error()})
-		}
+	// Posts the viewer may not see are reported as missing
+	if err := h.posts.ShowTo(c.Request.Context(), viewerID, post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
-	case post := <-postChan:
-		// Set cache headers
-		c.Header("Cache-Control", "private, max-age=300")
-		c.JSON(http.StatusOK, post)
 	}
+
+	// Set cache headers
+	c.Header("Cache-Control", "private, max-age=300")
+	c.JSON(http.StatusOK, post)
 }
\ No newline at end of file
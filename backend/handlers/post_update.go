@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"instagram-backend/cache"
-	"instagram-backend/models"
-	"log"
+	"instagram-backend/contentfilter"
 	"net/http"
 	"strconv"
 
@@ -12,17 +10,20 @@ import (
 
 func (h *PostHandler) UpdatePost(c *gin.Context) {
 	userID := c.GetUint("user_id")
-	postID := c.Param("id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
 
 	// Invalidate the post cache before updating
-	id, _ := strconv.ParseUint(postID, 10, 32)
-	if err := cache.InvalidatePostCache(c.Request.Context(), uint(id)); err != nil {
-		log.Printf("Failed to invalidate post cache: %v", err)
+	if err := h.cache.InvalidatePostCache(c.Request.Context(), uint(id)); err != nil {
+		requestLogger(c).Warn("Failed to invalidate post cache", "post_id", id, "error", err)
 	}
 
 	// Find the post
-	var post models.Post
-	if err := h.db.First(&post, postID).Error; err != nil {
+	post, err := h.repos.Posts.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
@@ -40,12 +41,18 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	verdict := h.screenText(c, &contentfilter.Input{Kind: contentfilter.KindCaption, Text: updateData.Caption, AuthorID: userID})
+	if verdict.Action == contentfilter.Reject {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Caption " + verdict.Reason})
+		return
+	}
+
 	// Update post fields
 	post.Caption = updateData.Caption
 	post.Location = updateData.Location
 
 	// Update the post
-	if err := h.db.Save(&post).Error; err != nil {
+	if err := h.repos.Posts.Update(c.Request.Context(), post, "Caption", "Location"); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
 		return
 	}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublicProfile is the subset of a user that may be shown without signing in.
+type PublicProfile struct {
+	ID               uint      `json:"id"`
+	Username         string    `json:"username"`
+	Name             string    `json:"name"`
+	Bio              string    `json:"bio"`
+	ProfileImage     string    `json:"profileImage"`
+	Role             string    `json:"role"`
+	Category         string    `json:"category,omitempty"`
+	Verified         bool      `json:"verified"`
+	PostsCount       int64     `json:"postsCount"`
+	SubscribersCount int64     `json:"subscribersCount"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+func loadPublicProfile(ctx context.Context, repos *repository.Repositories, user *models.User) (*PublicProfile, error) {
+	profile := &PublicProfile{
+		ID:           user.ID,
+		Username:     user.Username,
+		Name:         user.Name,
+		Bio:          user.Bio,
+		ProfileImage: user.ProfileImage,
+		Role:         user.Role,
+		Verified:     user.Verified,
+		CreatedAt:    user.CreatedAt,
+	}
+
+	if user.CategoryID != nil {
+		if category, err := repos.Categories.FindByID(ctx, *user.CategoryID); err == nil {
+			profile.Category = category.Slug
+		}
+	}
+	var err error
+	if profile.PostsCount, err = repos.Posts.CountPublished(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if profile.SubscribersCount, err = repos.Subscriptions.CountSubscribers(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// usernameParam reads a *username catch-all parameter; seller usernames
+// contain a slash so they can't be matched by a single path segment.
+func usernameParam(c *gin.Context) string {
+	return strings.Trim(c.Param("username"), "/")
+}
+
+// usernamePath joins prefix and username into an escaped URL path.
+func usernamePath(prefix, username string) string {
+	return (&url.URL{Path: prefix + username}).EscapedPath()
+}
+
+// findByUsername resolves the username parameter and answers 404, or a 301 to
+// prefix+current username when an old handle was requested. ok is false when
+// a response has already been written.
+func findByUsername(c *gin.Context, users repository.UserRepository, prefix string) (user *models.User, ok bool) {
+	user, redirected, err := users.FindByUsername(c.Request.Context(), usernameParam(c))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		return nil, false
+	}
+	if redirected {
+		c.Redirect(http.StatusMovedPermanently, usernamePath(prefix, user.Username))
+		return nil, false
+	}
+	return user, true
+}
+
+// @Summary Get user by username
+// @Description Get a user profile by username. Old usernames redirect to the current one.
+// @Tags users
+// @Produce json
+// @Param username path string true "Username, e.g. clothes/tosif"
+// @Success 200 {object} models.SwaggerUser
+// @Success 301 "Moved to the user's current username"
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/by-username/{username} [get]
+func (h *AuthHandler) GetUserByUsername(c *gin.Context) {
+	user, ok := findByUsername(c, h.repos.Users, "/api/v1/users/by-username/")
+	if !ok {
+		return
+	}
+
+	if err := h.users.CheckNotBlocked(c.Request.Context(), c.GetUint("user_id"), user.ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Get public profile
+// @Description Get the public fields of a profile with post and subscriber counts, without signing in
+// @Tags users
+// @Produce json
+// @Param username path string true "Username, e.g. clothes/tosif"
+// @Success 200 {object} PublicProfile
+// @Success 301 "Moved to the user's current username"
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/profiles/{username} [get]
+func (h *AuthHandler) GetPublicProfile(c *gin.Context) {
+	user, ok := findByUsername(c, h.repos.Users, "/api/v1/profiles/")
+	if !ok {
+		return
+	}
+
+	profile, err := loadPublicProfile(c.Request.Context(), h.repos, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, profile)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// settingEnabled reports whether a boolean admin setting is switched on.
+// Missing settings are treated as off.
+func settingEnabled(ctx context.Context, settings repository.SettingRepository, key string) bool {
+	value, err := settings.Get(ctx, key)
+	if err != nil {
+		return false
+	}
+	enabled, _ := strconv.ParseBool(value)
+	return enabled
+}
+
+// @Summary Get seller 2FA requirement
+// @Description Report whether sellers must enroll in two-factor authentication
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]bool
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/settings/seller-2fa [get]
+func (h *AuthHandler) GetSellerTwoFactorRequirement(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"required": settingEnabled(c.Request.Context(), h.repos.Settings, models.SettingRequireSellerTwoFactor)})
+}
+
+// @Summary Set seller 2FA requirement
+// @Description Require (or stop requiring) sellers to enroll in two-factor authentication
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param setting body map[string]bool true "Requirement, e.g. {\"required\": true}"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/settings/seller-2fa [put]
+func (h *AuthHandler) SetSellerTwoFactorRequirement(c *gin.Context) {
+	var req struct {
+		Required *bool `json:"required" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repos.Settings.Set(c.Request.Context(), models.SettingRequireSellerTwoFactor, strconv.FormatBool(*req.Required)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update setting"})
+		return
+	}
+	recordAudit(h.repos.Audit, c, audit.Entry{
+		Action:     audit.ActionSettingUpdate,
+		TargetType: audit.TargetSetting,
+		Details:    map[string]interface{}{"key": models.SettingRequireSellerTwoFactor, "value": *req.Required},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"required": *req.Required})
+}
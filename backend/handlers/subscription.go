@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"instagram-backend/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Subscribe to a seller
+// @Description Subscribe to a seller. Private sellers must approve the request first.
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Success 201 {object} models.Subscription "Subscribed"
+// @Success 202 {object} models.Subscription "Request pending approval"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/{id}/subscribe [post]
+func (h *AuthHandler) Subscribe(c *gin.Context) {
+	sellerID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	subscription, err := h.subscriptions.Subscribe(c.Request.Context(), c.GetUint("user_id"), uint(sellerID))
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Seller not found"})
+		return
+	case errors.Is(err, service.ErrSelfSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrAlreadySubscribed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": subscription.Status})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe"})
+		return
+	}
+
+	if subscription.Status == models.SubscriptionPending {
+		c.JSON(http.StatusAccepted, subscription)
+		return
+	}
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// @Summary Unsubscribe from a seller
+// @Description Unsubscribe from a seller, or withdraw a pending request
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/{id}/subscribe [delete]
+func (h *AuthHandler) Unsubscribe(c *gin.Context) {
+	sellerID, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	err := h.subscriptions.Unsubscribe(c.Request.Context(), c.GetUint("user_id"), uint(sellerID))
+	if errors.Is(err, service.ErrNotSubscribed) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed successfully"})
+}
+
+// @Summary List subscription requests
+// @Description List pending subscription requests to the current (private) seller
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {array} models.Subscription
+// @Security BearerAuth
+// @Router /api/v1/me/subscription-requests [get]
+func (h *AuthHandler) ListSubscriptionRequests(c *gin.Context) {
+	requests, err := h.subscriptions.Requests(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscription requests"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// @Summary Approve subscription request
+// @Description Approve a pending subscription request to the current seller
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.Subscription
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/subscription-requests/{id}/approve [post]
+func (h *AuthHandler) ApproveSubscriptionRequest(c *gin.Context) {
+	id, ok := subscriptionRequestID(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.subscriptions.Approve(c.Request.Context(), c.GetUint("user_id"), id)
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription request not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// @Summary Deny subscription request
+// @Description Deny a pending subscription request to the current seller
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/subscription-requests/{id}/deny [post]
+func (h *AuthHandler) DenySubscriptionRequest(c *gin.Context) {
+	id, ok := subscriptionRequestID(c)
+	if !ok {
+		return
+	}
+
+	err := h.subscriptions.Deny(c.Request.Context(), c.GetUint("user_id"), id)
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription request not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deny request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Request denied"})
+}
+
+// subscriptionRequestID parses the request ID in the path, answering 404 when
+// it isn't one.
+func subscriptionRequestID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription request not found"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// @Summary Update privacy
+// @Description Make the current account private or public. Going public approves all pending requests.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param privacy body map[string]bool true "Privacy, e.g. {\"isPrivate\": true}"
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/privacy [put]
+func (h *AuthHandler) UpdatePrivacy(c *gin.Context) {
+	var req struct {
+		IsPrivate *bool `json:"isPrivate" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+	err := h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		user, err := tx.Users.FindByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		wasPrivate := user.IsPrivate
+		user.IsPrivate = *req.IsPrivate
+		if err := tx.Users.Update(ctx, user, "IsPrivate"); err != nil {
+			return err
+		}
+		if wasPrivate != *req.IsPrivate {
+			if err := recordAudit(tx.Audit, c, audit.Entry{
+				Action:     audit.ActionUserUpdate,
+				TargetType: audit.TargetUser,
+				TargetID:   userID,
+				Changes:    map[string]audit.Change{"isPrivate": {From: wasPrivate, To: *req.IsPrivate}},
+			}); err != nil {
+				return err
+			}
+		}
+		if *req.IsPrivate {
+			return nil
+		}
+		return tx.Subscriptions.ApproveAll(ctx, userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update privacy"})
+		return
+	}
+
+	// Cached posts embed the author, including whether they are private
+	invalidateAuthorCaches(c, h.repos.Posts, h.cache, userID)
+
+	c.JSON(http.StatusOK, gin.H{"isPrivate": *req.IsPrivate})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashResponse lists what the current user deleted recently enough to restore.
+type TrashResponse struct {
+	Posts    []models.Post    `json:"posts"`
+	Comments []models.Comment `json:"comments"`
+}
+
+// trashSince is the earliest deletion time that can still be restored.
+func (h *PostHandler) trashSince() time.Time {
+	return time.Now().Add(-h.trashRetention)
+}
+
+// @Summary List recently deleted
+// @Description List the current user's deleted posts and comments that can still be restored
+// @Tags posts
+// @Produce json
+// @Success 200 {object} TrashResponse
+// @Security BearerAuth
+// @Router /api/v1/me/trash [get]
+func (h *PostHandler) ListTrash(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	posts, err := h.repos.Posts.ListTrashed(c.Request.Context(), userID, h.trashSince())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted posts"})
+		return
+	}
+	comments, err := h.repos.Comments.ListTrashed(c.Request.Context(), userID, h.trashSince())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted comments"})
+		return
+	}
+
+	trash := TrashResponse{Posts: []models.Post{}, Comments: []models.Comment{}}
+	trash.Posts = append(trash.Posts, posts...)
+	trash.Comments = append(trash.Comments, comments...)
+	c.JSON(http.StatusOK, trash)
+}
+
+// @Summary Restore post
+// @Description Restore one of the current user's deleted posts along with its images, purchase options, likes and comments
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} models.Post
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/trash/posts/{id}/restore [post]
+func (h *PostHandler) RestorePost(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found in trash"})
+		return
+	}
+	ctx := c.Request.Context()
+	post, err := h.repos.Posts.FindTrashed(ctx, id, c.GetUint("user_id"), h.trashSince())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found in trash"})
+		return
+	}
+
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Posts.Restore(ctx, post.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{Action: audit.ActionPostRestore, TargetType: audit.TargetPost, TargetID: post.ID})
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found in trash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore post"})
+		return
+	}
+
+	if err := h.cache.InvalidatePostCache(c.Request.Context(), post.ID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate post cache", "post_id", post.ID, "error", err)
+	}
+
+	restored, err := h.repos.Posts.FindWithMedia(ctx, post.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load post"})
+		return
+	}
+	c.JSON(http.StatusOK, restored)
+}
+
+// @Summary Restore comment
+// @Description Restore one of the current user's deleted comments, as long as its post still exists
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} models.Comment
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/trash/comments/{id}/restore [post]
+func (h *PostHandler) RestoreComment(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found in trash"})
+		return
+	}
+	ctx := c.Request.Context()
+	comment, err := h.repos.Comments.FindTrashed(ctx, id, c.GetUint("user_id"), h.trashSince())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found in trash"})
+		return
+	}
+
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Comments.Restore(ctx, comment); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionCommentRestore,
+			TargetType: audit.TargetComment,
+			TargetID:   comment.ID,
+			Details:    map[string]interface{}{"postId": comment.PostID},
+		})
+	})
+	if errors.Is(err, repository.ErrParentPostDeleted) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found in trash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore comment"})
+		return
+	}
+
+	// The post embeds its comments in the cache
+	if err := h.cache.InvalidatePostCache(c.Request.Context(), comment.PostID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate post cache", "post_id", comment.PostID, "error", err)
+	}
+
+	restored, err := h.repos.Comments.FindWithAuthor(ctx, comment.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load comment"})
+		return
+	}
+	c.JSON(http.StatusOK, restored)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"instagram-backend/audit"
+	"instagram-backend/cache"
+	"instagram-backend/config"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"instagram-backend/service"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// newTrashTestRouter serves the post and trash routes for user 1 on in-memory
+// repositories, with one post (10) by that user and a comment (20) on it.
+func newTrashTestRouter(t *testing.T) (*gin.Engine, *repository.Memory) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mem := &repository.Memory{
+		Users: []models.User{{Model: gorm.Model{ID: 1}, Username: "seller", Role: "seller"}},
+		Posts: []models.Post{{Model: gorm.Model{ID: 10}, UserID: 1, Caption: "hello", ContentType: "feed"}},
+		Comments: []models.Comment{{Model: gorm.Model{ID: 20}, PostID: 10, UserID: 1, Content: "first",
+			Status: models.CommentPublished}},
+	}
+	repos := mem.Repositories()
+
+	mr := miniredis.RunT(t)
+	redisCache := cache.New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), cache.Options{})
+	h := NewPostHandler(config.Default(), repos, service.New(repos), redisCache, nil, nil)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	r.DELETE("/posts/:id", h.DeletePost)
+	r.GET("/me/trash", h.ListTrash)
+	r.POST("/me/trash/posts/:id/restore", h.RestorePost)
+	return r, mem
+}
+
+func serveTrashTest(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestDeletedPostCanBeRestoredFromTrash(t *testing.T) {
+	r, mem := newTrashTestRouter(t)
+
+	if w := serveTrashTest(r, http.MethodDelete, "/posts/10"); w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body %s", w.Code, w.Body)
+	}
+	if len(mem.AuditEvents) != 1 || mem.AuditEvents[0].Action != audit.ActionPostDelete {
+		t.Fatalf("audit events = %+v, want one post.delete", mem.AuditEvents)
+	}
+
+	w := serveTrashTest(r, http.MethodGet, "/me/trash")
+	var trash TrashResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &trash); err != nil {
+		t.Fatalf("decode trash: %v", err)
+	}
+	if len(trash.Posts) != 1 || trash.Posts[0].ID != 10 {
+		t.Fatalf("trashed posts = %+v, want post 10", trash.Posts)
+	}
+	// The comment went with its post, so it isn't listed on its own
+	if len(trash.Comments) != 0 {
+		t.Fatalf("trashed comments = %+v, want none", trash.Comments)
+	}
+
+	if w := serveTrashTest(r, http.MethodPost, "/me/trash/posts/10/restore"); w.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, body %s", w.Code, w.Body)
+	}
+	if mem.Posts[0].DeletedAt.Valid || mem.Comments[0].DeletedAt.Valid {
+		t.Fatalf("post or comment still deleted after restore")
+	}
+	if w := serveTrashTest(r, http.MethodPost, "/me/trash/posts/10/restore"); w.Code != http.StatusNotFound {
+		t.Fatalf("second restore status = %d, want 404", w.Code)
+	}
+}
+
+func TestDeletePostRejectsNonNumericID(t *testing.T) {
+	r, mem := newTrashTestRouter(t)
+
+	if w := serveTrashTest(r, http.MethodDelete, "/posts/10%20OR%201=1"); w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+	if mem.Posts[0].DeletedAt.Valid {
+		t.Fatalf("post deleted through a non-numeric ID")
+	}
+}
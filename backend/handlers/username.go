@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"instagram-backend/audit"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handlePattern is a single username segment: 3-30 lowercase letters, digits,
+// dots or underscores, starting and ending with a letter or digit.
+var handlePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._]{1,28}[a-z0-9]$`)
+
+// reservedUsernames can't be claimed as a handle or category slug because
+// they collide with routes or could be used to impersonate staff.
+var reservedUsernames = map[string]bool{
+	"about": true, "admin": true, "administrator": true, "api": true, "app": true,
+	"categories": true, "explore": true, "help": true, "instagram": true, "login": true,
+	"logout": true, "me": true, "moderator": true, "official": true, "p": true,
+	"register": true, "root": true, "security": true, "settings": true, "staff": true,
+	"static": true, "support": true, "system": true, "u": true, "www": true,
+}
+
+var errUsernameTaken = errors.New("Username is already taken")
+
+// validateUsername normalises a requested username and checks it against the
+// role's format: sellers use "<category-slug>/<handle>" with an existing
+// category, buyers a bare handle. It returns the category for sellers.
+func validateUsername(ctx context.Context, categories repository.CategoryRepository, username, role string) (string, *models.Category, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+
+	if role != "seller" {
+		if err := validateHandle(username); err != nil {
+			return "", nil, err
+		}
+		return username, nil, nil
+	}
+
+	slug, handle, ok := strings.Cut(username, "/")
+	if !ok || strings.Contains(handle, "/") {
+		return "", nil, errors.New("Seller usernames must look like category/handle, e.g. clothes/tosif")
+	}
+	if err := validateHandle(handle); err != nil {
+		return "", nil, err
+	}
+
+	category, err := categories.FindBySlug(ctx, slug)
+	if err != nil {
+		return "", nil, fmt.Errorf("Unknown category %q", slug)
+	}
+	return username, category, nil
+}
+
+func validateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return errors.New("Usernames must be 3-30 characters of lowercase letters, digits, dots or underscores")
+	}
+	if reservedUsernames[handle] {
+		return fmt.Errorf("The username %q is reserved", handle)
+	}
+	return nil
+}
+
+// @Summary Change username
+// @Description Change the current user's username. The old username keeps redirecting to the account.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param username body map[string]string true "New username, e.g. {\"username\": \"clothes/tosif\"}"
+// @Success 200 {object} models.SwaggerUser
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string "Changed too recently"
+// @Security BearerAuth
+// @Router /api/v1/me/username [put]
+func (h *AuthHandler) ChangeUsername(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.repos.Users.FindByID(ctx, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	username, category, err := validateUsername(ctx, h.repos.Categories, req.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if username == user.Username {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	if user.UsernameChangedAt != nil {
+		if wait := h.cfg.Auth.UsernameChangeCooldown - time.Since(*user.UsernameChangedAt); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Username was changed recently, please try again later"})
+			return
+		}
+	}
+
+	oldUsername := user.Username
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		available, err := tx.Users.UsernameAvailable(ctx, username, user.ID)
+		if err != nil {
+			return err
+		}
+		if !available {
+			return errUsernameTaken
+		}
+
+		// Reclaiming one of the user's own old usernames drops its redirect.
+		if err := tx.Users.Rename(ctx, user.ID, oldUsername, username); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		user.Username = username
+		user.UsernameChangedAt = &now
+		user.CategoryID = nil
+		if category != nil {
+			user.CategoryID = &category.ID
+		}
+		if err := tx.Users.Update(ctx, user, "Username", "UsernameChangedAt", "CategoryID"); err != nil {
+			return err
+		}
+		// The badge vouches for the handle that was reviewed
+		if err := revokeVerification(tx, c, user.ID, "username changed"); err != nil {
+			return err
+		}
+		user.Verified, user.VerifiedAt = false, nil
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionUserUpdate,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Changes:    map[string]audit.Change{"username": {From: oldUsername, To: username}},
+		})
+	})
+	if errors.Is(err, errUsernameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change username"})
+		return
+	}
+
+	invalidateAuthorCaches(c, h.repos.Posts, h.cache, user.ID)
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"instagram-backend/audit"
+	"instagram-backend/cache"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errVerificationReviewed = errors.New("Verification request was already reviewed")
+
+type VerificationHandler struct {
+	repos *repository.Repositories
+	cache *cache.Cache
+}
+
+func NewVerificationHandler(repos *repository.Repositories, cache *cache.Cache) *VerificationHandler {
+	return &VerificationHandler{repos: repos, cache: cache}
+}
+
+type VerificationSubmitRequest struct {
+	LegalName string `json:"legalName" binding:"required,max=200"`
+	Website   string `json:"website" binding:"omitempty,url,max=500"`
+	// Documents are references to supporting documents, e.g. links to uploads.
+	Documents []string `json:"documents" binding:"required,min=1,max=10,dive,required,max=500"`
+	Notes     string   `json:"notes" binding:"max=1000"`
+}
+
+type VerificationRejectRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// revokeVerification removes a user's verified badge, e.g. when they rename
+// the handle that was verified. It does nothing for unverified users.
+func revokeVerification(tx *repository.Repositories, c *gin.Context, userID uint, reason string) error {
+	revoked, err := tx.Users.RevokeVerification(c.Request.Context(), userID)
+	if err != nil || !revoked {
+		return err
+	}
+	return recordAudit(tx.Audit, c, audit.Entry{
+		Action:     audit.ActionVerificationRevoke,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+		Details:    map[string]interface{}{"reason": reason},
+	})
+}
+
+// invalidateAuthorCaches drops the cached user and every cached post that
+// embeds them.
+func invalidateAuthorCaches(c *gin.Context, posts repository.PostRepository, cached *cache.Cache, userID uint) {
+	if err := cached.InvalidateUserCache(c.Request.Context(), userID); err != nil {
+		requestLogger(c).Warn("Failed to invalidate user cache", "user_id", userID, "error", err)
+	}
+	postIDs, _ := posts.IDsByAuthor(c.Request.Context(), userID)
+	for _, postID := range postIDs {
+		if err := cached.InvalidatePostCache(c.Request.Context(), postID); err != nil {
+			requestLogger(c).Warn("Failed to invalidate post cache", "post_id", postID, "error", err)
+		}
+	}
+}
+
+// @Summary Request verification
+// @Description Apply for the verified seller badge with references to supporting documents
+// @Tags verification
+// @Accept json
+// @Produce json
+// @Param request body VerificationSubmitRequest true "Verification request"
+// @Success 201 {object} models.VerificationRequest
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/verification [post]
+func (h *VerificationHandler) SubmitVerification(c *gin.Context) {
+	var req VerificationSubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.repos.Users.FindByID(ctx, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Role != "seller" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only sellers can be verified"})
+		return
+	}
+	if user.Verified {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is already verified"})
+		return
+	}
+
+	if pending, _ := h.repos.Verifications.HasPending(ctx, user.ID); pending {
+		c.JSON(http.StatusConflict, gin.H{"error": repository.ErrVerificationPending.Error()})
+		return
+	}
+
+	documents, _ := json.Marshal(req.Documents)
+	request := models.VerificationRequest{
+		UserID:    user.ID,
+		LegalName: req.LegalName,
+		Website:   req.Website,
+		Documents: models.JSONText(documents),
+		Notes:     req.Notes,
+		Status:    models.VerificationPending,
+	}
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Verifications.Create(ctx, &request); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionVerificationSubmit,
+			TargetType: audit.TargetVerification,
+			TargetID:   request.ID,
+		})
+	})
+	if errors.Is(err, repository.ErrVerificationPending) {
+		// A concurrent submission got in after the check above.
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit verification request"})
+		return
+	}
+
+	request.User = *user
+	c.JSON(http.StatusCreated, request)
+}
+
+// @Summary Get verification status
+// @Description Get the current user's most recent verification request
+// @Tags verification
+// @Produce json
+// @Success 200 {object} models.VerificationRequest
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/me/verification [get]
+func (h *VerificationHandler) GetVerification(c *gin.Context) {
+	request, err := h.repos.Verifications.Latest(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No verification request"})
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// @Summary List verification requests
+// @Description List verification requests, oldest first, by default only those awaiting review
+// @Tags admin
+// @Produce json
+// @Param status query string false "pending, approved or rejected"
+// @Success 200 {array} models.VerificationRequest
+// @Security BearerAuth
+// @Router /api/v1/admin/verifications [get]
+func (h *VerificationHandler) ListVerifications(c *gin.Context) {
+	status := c.DefaultQuery("status", models.VerificationPending)
+
+	requests, err := h.repos.Verifications.List(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch verification requests"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// @Summary Approve verification
+// @Description Approve a verification request and give the seller the verified badge
+// @Tags admin
+// @Produce json
+// @Param id path int true "Verification request ID"
+// @Success 200 {object} models.VerificationRequest
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/verifications/{id}/approve [post]
+func (h *VerificationHandler) ApproveVerification(c *gin.Context) {
+	h.review(c, models.VerificationApproved, "")
+}
+
+// @Summary Reject verification
+// @Description Reject a verification request, telling the seller why
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Verification request ID"
+// @Param rejection body VerificationRejectRequest true "Reason"
+// @Success 200 {object} models.VerificationRequest
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/verifications/{id}/reject [post]
+func (h *VerificationHandler) RejectVerification(c *gin.Context) {
+	var req VerificationRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.review(c, models.VerificationRejected, req.Reason)
+}
+
+func (h *VerificationHandler) review(c *gin.Context, status, reason string) {
+	id, ok := idParam(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verification request not found"})
+		return
+	}
+	ctx := c.Request.Context()
+	request, err := h.repos.Verifications.FindWithUser(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verification request not found"})
+		return
+	}
+	if status == models.VerificationApproved && request.User.Role != "seller" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only sellers can be verified"})
+		return
+	}
+
+	reviewerID := c.GetUint("user_id")
+	now := time.Now()
+	request.Status = status
+	request.Reason = reason
+	request.ReviewerID = &reviewerID
+	request.ReviewedAt = &now
+	err = h.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		reviewed, err := tx.Verifications.Review(ctx, request)
+		if err != nil {
+			return err
+		}
+		if !reviewed {
+			return errVerificationReviewed
+		}
+
+		if status == models.VerificationApproved {
+			request.User.Verified = true
+			request.User.VerifiedAt = &now
+			if err := tx.Users.Update(ctx, &request.User, "Verified", "VerifiedAt"); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx.Audit, c, audit.Entry{
+			Action:     audit.ActionVerificationReview,
+			TargetType: audit.TargetVerification,
+			TargetID:   request.ID,
+			Details:    map[string]interface{}{"status": status, "reason": reason, "userId": request.UserID},
+		})
+	})
+	if errors.Is(err, errVerificationReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review verification request"})
+		return
+	}
+
+	if status == models.VerificationApproved {
+		invalidateAuthorCaches(c, h.repos.Posts, h.cache, request.UserID)
+	}
+
+	c.JSON(http.StatusOK, request)
+}
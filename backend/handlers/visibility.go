@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"errors"
+	"instagram-backend/models"
+	"instagram-backend/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// findVisiblePost loads a post with its author and answers 404 when it doesn't
+// exist or the current user may not see it. ok is false when a response has
+// already been written.
+func (h *PostHandler) findVisiblePost(c *gin.Context, postID uint) (post *models.Post, ok bool) {
+	post, err := h.posts.Visible(c.Request.Context(), c.GetUint("user_id"), postID)
+	if errors.Is(err, service.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post"})
+		return nil, false
+	}
+	return post, true
+}
@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"context"
+	"instagram-backend/audit"
+	"instagram-backend/cache"
+	"instagram-backend/logging"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeDeletedAccounts hard-deletes every account whose deletion grace period
+// has ended.
+func PurgeDeletedAccounts(ctx context.Context, db *gorm.DB, cached *cache.Cache) error {
+	var userIDs []uint
+	if err := db.WithContext(ctx).Model(&models.User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	audits := repository.NewGorm(db).Audit
+	logger := logging.FromContext(ctx)
+	for _, userID := range userIDs {
+		if err := PurgeUser(ctx, db, cached, userID); err != nil {
+			logger.ErrorContext(ctx, "Failed to purge user", "user_id", userID, "error", err)
+			continue
+		}
+		logger.InfoContext(ctx, "Purged user", "user_id", userID)
+		event, err := audit.Event(nil, audit.Entry{
+			Action:     audit.ActionUserPurge,
+			TargetType: audit.TargetUser,
+			TargetID:   userID,
+		})
+		if err == nil {
+			err = audits.Record(ctx, event)
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to record audit event", "action", audit.ActionUserPurge, "error", err)
+		}
+	}
+	return nil
+}
+
+// PurgeUser permanently removes a user and everything they own: posts with
+// their images, purchase options, likes and comments, the user's own likes,
+// comments and subscriptions, credentials, exports, and the reports made by
+// or about them or their content. Cached copies are invalidated afterwards.
+func PurgeUser(ctx context.Context, db *gorm.DB, cached *cache.Cache, userID uint) error {
+	var postIDs, commentIDs []uint
+	var exports []models.DataExport
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).Pluck("id", &postIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ? OR post_id IN (?)", userID, postIDs).
+			Pluck("id", &commentIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Find(&exports).Error; err != nil {
+			return err
+		}
+
+		deletes := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&models.Like{}, "user_id = ? OR post_id IN (?)", []interface{}{userID, postIDs}},
+			{&models.Comment{}, "user_id = ? OR post_id IN (?)", []interface{}{userID, postIDs}},
+			{&models.PostImage{}, "post_id IN (?)", []interface{}{postIDs}},
+			{&models.PurchaseOption{}, "post_id IN (?)", []interface{}{postIDs}},
+			{&models.Post{}, "user_id = ?", []interface{}{userID}},
+			{&models.Subscription{}, "subscriber_id = ? OR seller_id = ?", []interface{}{userID, userID}},
+			{&models.Session{}, "user_id = ?", []interface{}{userID}},
+			{&models.APIKey{}, "user_id = ?", []interface{}{userID}},
+			{&models.RecoveryCode{}, "user_id = ?", []interface{}{userID}},
+			{&models.ExternalIdentity{}, "user_id = ?", []interface{}{userID}},
+			{&models.DataExport{}, "user_id = ?", []interface{}{userID}},
+			{&models.UsernameRedirect{}, "user_id = ?", []interface{}{userID}},
+			{&models.Block{}, "blocker_id = ? OR blocked_id = ?", []interface{}{userID, userID}},
+			{&models.Mute{}, "muter_id = ? OR muted_id = ?", []interface{}{userID, userID}},
+			{&models.Report{}, "reporter_id = ? OR (target_type = ? AND target_id = ?) OR " +
+				"(target_type = ? AND target_id IN (?)) OR (target_type = ? AND target_id IN (?))", []interface{}{
+				userID, models.ReportTargetUser, userID,
+				models.ReportTargetPost, postIDs, models.ReportTargetComment, commentIDs,
+			}},
+			// Appeals are made on the notices sent to the owner of the content
+			{&models.Notice{}, "user_id = ?", []interface{}{userID}},
+			{&models.BlockedKeyword{}, "seller_id = ?", []interface{}{userID}},
+			{&models.VerificationRequest{}, "user_id = ?", []interface{}{userID}},
+			{&models.User{}, "id = ?", []interface{}{userID}},
+		}
+		for _, d := range deletes {
+			if err := tx.Unscoped().Where(d.query, d.args...).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.FilePath != "" {
+			os.Remove(export.FilePath)
+		}
+	}
+
+	if err := cached.InvalidateUserCache(ctx, userID); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "Failed to invalidate user cache", "user_id", userID, "error", err)
+	}
+	for _, postID := range postIDs {
+		if err := cached.InvalidatePostCache(ctx, postID); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "Failed to invalidate post cache", "post_id", postID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"context"
+	"instagram-backend/logging"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeExpiredAuditEvents deletes audit events older than retention. This
+// is the only place events are deleted: models.AuditEvent refuses updates and
+// deletes made through GORM, so it goes around the hooks with raw SQL.
+func PurgeExpiredAuditEvents(ctx context.Context, db *gorm.DB, retention time.Duration) error {
+	result := db.WithContext(ctx).Exec("DELETE FROM audit_events WHERE created_at < ?", time.Now().Add(-retention))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logging.FromContext(ctx).InfoContext(ctx, "Purged expired audit events", "count", result.RowsAffected)
+	}
+	return nil
+}
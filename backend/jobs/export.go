@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"instagram-backend/config"
+	"instagram-backend/logging"
+	"instagram-backend/models"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BuildExport packages the user's profile, posts, comments, likes and
+// subscriptions into a zip archive in retention.ExportDir and marks the export
+// ready or failed.
+func BuildExport(ctx context.Context, db *gorm.DB, retention config.RetentionConfig, exportID uint) {
+	logger := logging.FromContext(ctx).With("export_id", exportID)
+
+	var export models.DataExport
+	if err := db.WithContext(ctx).First(&export, exportID).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to load export", "error", err)
+		return
+	}
+
+	path, err := writeExportArchive(ctx, db, retention.ExportDir, export)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to build export", "error", err)
+		db.Model(&export).Update("status", "failed")
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(retention.Export)
+	if err := db.Model(&export).Updates(map[string]interface{}{
+		"status":       "ready",
+		"file_path":    path,
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}).Error; err != nil {
+		logger.ErrorContext(ctx, "Failed to update export", "error", err)
+		os.Remove(path)
+	}
+}
+
+func writeExportArchive(ctx context.Context, db *gorm.DB, dir string, export models.DataExport) (string, error) {
+	db = db.WithContext(ctx)
+
+	var user models.User
+	if err := db.First(&user, export.UserID).Error; err != nil {
+		return "", err
+	}
+
+	var posts []models.Post
+	if err := db.Preload("PostImages").Preload("PurchaseOptions").
+		Where("user_id = ?", user.ID).Order("created_at").Find(&posts).Error; err != nil {
+		return "", err
+	}
+
+	var comments []models.Comment
+	if err := db.Where("user_id = ?", user.ID).Order("created_at").Find(&comments).Error; err != nil {
+		return "", err
+	}
+
+	var likes []models.Like
+	if err := db.Where("user_id = ?", user.ID).Order("created_at").Find(&likes).Error; err != nil {
+		return "", err
+	}
+
+	var subscriptions []models.Subscription
+	if err := db.Where("subscriber_id = ? OR seller_id = ?", user.ID, user.ID).
+		Order("created_at").Find(&subscriptions).Error; err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("export-%d-%d.zip", user.ID, export.ID))
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"posts.json", posts},
+		{"comments.json", comments},
+		{"likes.json", likes},
+		{"subscriptions.json", subscriptions},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			os.Remove(path)
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// PurgeExpiredExports deletes archives past their download window.
+func PurgeExpiredExports(ctx context.Context, db *gorm.DB) error {
+	var exports []models.DataExport
+	if err := db.WithContext(ctx).Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).
+		Find(&exports).Error; err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				logging.FromContext(ctx).ErrorContext(ctx, "Failed to remove export file", "export_id", export.ID, "path", export.FilePath, "error", err)
+				continue
+			}
+		}
+		db.WithContext(ctx).Unscoped().Delete(&export)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Package jobs holds background work that runs outside the request cycle,
+// such as purging deleted accounts and building data exports.
+package jobs
+
+import (
+	"context"
+	"instagram-backend/logging"
+	"time"
+)
+
+// RunPeriodically calls fn every interval until ctx is cancelled, logging
+// failures instead of stopping. fn logs with ctx's logger tagged with the
+// job's name.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	logger := logging.FromContext(ctx).With("job", name)
+	ctx = logging.WithContext(ctx, logger)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := fn(ctx); err != nil {
+			logger.ErrorContext(ctx, "Job failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"instagram-backend/logging"
+	"instagram-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeExpiredTrash hard-deletes posts and comments their owners deleted
+// longer ago than they can be restored, together with the images, purchase
+// options, likes and comments of those posts. Content an admin deleted or a
+// moderator removed is kept as evidence for appeals and the audit log.
+func PurgeExpiredTrash(ctx context.Context, db *gorm.DB, retention time.Duration) error {
+	cutoff := time.Now().Add(-retention)
+	expired := func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where("deleted_at < ? AND deleted_by_id = user_id AND moderation_status <> ?",
+			cutoff, models.ModerationRemoved)
+	}
+	var purgedPosts, purgedComments int64
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var postIDs []uint
+		if err := tx.Model(&models.Post{}).Scopes(expired).Pluck("id", &postIDs).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{&models.Like{}, &models.Comment{}, &models.PostImage{}, &models.PurchaseOption{}} {
+			if err := tx.Unscoped().Where("post_id IN (?)", postIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().Where("id IN (?)", postIDs).Delete(&models.Post{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purgedPosts = result.RowsAffected
+
+		result = tx.Scopes(expired).Delete(&models.Comment{})
+		purgedComments = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return err
+	}
+	if purgedPosts > 0 || purgedComments > 0 {
+		logging.FromContext(ctx).InfoContext(ctx, "Purged deleted content", "posts", purgedPosts, "comments", purgedComments)
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+// Package keys manages the asymmetric keys that sign JWTs. Keys are identified
+// by kid, stored encrypted in the database so every replica shares them, and
+// rotated on a schedule with an overlap window so tokens signed by a retired
+// key stay verifiable until they expire.
+package keys
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"instagram-backend/logging"
+	"instagram-backend/models"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// Options controls key generation and rotation.
+type Options struct {
+	// Algorithm is AlgEdDSA or AlgRS256.
+	Algorithm string
+	// RotationInterval is how long a key signs before its successor takes over.
+	RotationInterval time.Duration
+	// PrePublish is how long a new key is published in the JWKS before it
+	// starts signing, so verifiers have fetched it by the time it is used.
+	PrePublish time.Duration
+	// Overlap is how long a retired key is still accepted and published. It
+	// must cover the lifetime of the tokens it signed.
+	Overlap time.Duration
+	// EncryptionKey is the 32-byte AES key protecting private keys at rest.
+	EncryptionKey []byte
+}
+
+// Key is one signing key with its schedule.
+type Key struct {
+	ID          string
+	Algorithm   string
+	Private     crypto.Signer
+	Public      crypto.PublicKey
+	ActivatesAt time.Time
+	RetiresAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// KeySet signs and verifies tokens with the current generation of keys.
+type KeySet struct {
+	db   *gorm.DB
+	opts Options
+	aead cipher.AEAD
+
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+// NewKeySet loads the stored keys and creates a signing key if none is active.
+func NewKeySet(ctx context.Context, db *gorm.DB, opts Options) (*KeySet, error) {
+	block, err := aes.NewCipher(opts.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{db: db, opts: opts, aead: aead}
+	if err := ks.Rotate(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Run refreshes and rotates keys every interval until ctx is cancelled.
+func (ks *KeySet) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Rotate(ctx); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "Failed to rotate signing keys", "error", err)
+			}
+		}
+	}
+}
+
+// Rotate reloads the keys from the database and schedules a successor once
+// the newest key is within PrePublish of retiring.
+func (ks *KeySet) Rotate(ctx context.Context) error {
+	if err := ks.load(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	newest := ks.newest()
+	switch {
+	case newest == nil || !now.Before(newest.RetiresAt):
+		// Nothing usable: start signing immediately.
+		if err := ks.create(ctx, now); err != nil {
+			return err
+		}
+	case !now.Before(newest.RetiresAt.Add(-ks.opts.PrePublish)):
+		if err := ks.create(ctx, newest.RetiresAt); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	return ks.load(ctx)
+}
+
+// Sign signs claims with the active key and sets the kid header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := ks.signingKey(time.Now())
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc resolves the verification key for a token, insisting that the
+// token's algorithm matches the one the key was created for.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key := ks.lookup(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+// Parse verifies a token signed by this key set.
+func (ks *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}))
+	return parser.Parse(tokenString, ks.Keyfunc)
+}
+
+// PublicKeys returns every key that may still verify tokens, including keys
+// that are published ahead of activation.
+func (ks *KeySet) PublicKeys() []*Key {
+	now := time.Now()
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	var keys []*Key
+	for _, key := range ks.keys {
+		if now.Before(key.ExpiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (ks *KeySet) signingKey(now time.Time) *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	// Keys are sorted newest first.
+	for _, key := range ks.keys {
+		if !now.Before(key.ActivatesAt) && now.Before(key.RetiresAt) {
+			return key
+		}
+	}
+	return nil
+}
+
+func (ks *KeySet) lookup(kid string) *Key {
+	now := time.Now()
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, key := range ks.keys {
+		if key.ID == kid && now.Before(key.ExpiresAt) {
+			return key
+		}
+	}
+	return nil
+}
+
+func (ks *KeySet) newest() *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if len(ks.keys) == 0 {
+		return nil
+	}
+	return ks.keys[0]
+}
+
+func (ks *KeySet) load(ctx context.Context) error {
+	var records []models.SigningKey
+	if err := ks.db.WithContext(ctx).Where("expires_at > ?", time.Now()).Find(&records).Error; err != nil {
+		return fmt.Errorf("load signing keys: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(records))
+	for _, record := range records {
+		key, err := ks.decode(record)
+		if err != nil {
+			return fmt.Errorf("decode signing key %s: %w", record.ID, err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActivatesAt.After(keys[j].ActivatesAt) })
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+// create stores a new key that starts signing at activatesAt. The kid is
+// derived from the activation time so replicas racing to create the same
+// successor collapse onto a single row.
+func (ks *KeySet) create(ctx context.Context, activatesAt time.Time) error {
+	activatesAt = activatesAt.UTC().Truncate(time.Second)
+
+	var private crypto.Signer
+	switch ks.opts.Algorithm {
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return err
+		}
+		private = key
+	default:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		private = key
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, ks.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	retiresAt := activatesAt.Add(ks.opts.RotationInterval)
+	record := models.SigningKey{
+		ID:          fmt.Sprintf("%s-%d", ks.opts.Algorithm, activatesAt.Unix()),
+		Algorithm:   ks.opts.Algorithm,
+		PrivateKey:  ks.aead.Seal(nonce, nonce, privateDER, nil),
+		PublicKey:   publicDER,
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		ExpiresAt:   retiresAt.Add(ks.opts.Overlap),
+	}
+
+	if err := ks.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+		return fmt.Errorf("store signing key: %w", err)
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "Created signing key", "kid", record.ID, "activates_at", activatesAt)
+	return nil
+}
+
+func (ks *KeySet) decode(record models.SigningKey) (*Key, error) {
+	nonceSize := ks.aead.NonceSize()
+	if len(record.PrivateKey) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	privateDER, err := ks.aead.Open(nil, record.PrivateKey[:nonceSize], record.PrivateKey[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.New("cannot decrypt private key, check JWT_KEY_ENCRYPTION_KEY")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(privateDER)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	return &Key{
+		ID:          record.ID,
+		Algorithm:   record.Algorithm,
+		Private:     private,
+		Public:      private.Public(),
+		ActivatesAt: record.ActivatesAt,
+		RetiresAt:   record.RetiresAt,
+		ExpiresAt:   record.ExpiresAt,
+	}, nil
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	if alg == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
@@ -0,0 +1,89 @@
+// Package logging sets up structured logging with log/slog. Each request's
+// logger, tagged with its request ID, travels in the request context so
+// handlers, the cache and database queries log with it.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// Options configures New.
+type Options struct {
+	Level slog.Level
+	// Format is "json" or "text".
+	Format string
+}
+
+// New returns a logger writing to w. Attributes that look like secrets are
+// redacted; see Redact.
+func New(w io.Writer, opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{
+		Level:       opts.Level,
+		ReplaceAttr: Redact,
+	}
+	if opts.Format == "text" {
+		return slog.New(slog.NewTextHandler(w, handlerOpts))
+	}
+	return slog.New(slog.NewJSONHandler(w, handlerOpts))
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying logger.
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger ctx carries, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// redacted replaces secrets in log output.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are substrings of attribute keys whose values are never
+// logged.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie", "api_key", "apikey", "dsn", "database_url"}
+
+// Redact is a slog ReplaceAttr function that hides the values of attributes
+// whose keys name secrets, and passwords in URLs such as database DSNs.
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	if a.Value.Kind() == slog.KindString {
+		if s := a.Value.String(); strings.Contains(s, "://") {
+			return slog.String(a.Key, redactURLs(s))
+		}
+	}
+	return a
+}
+
+// redactURLs hides the passwords of the URLs in s.
+func redactURLs(s string) string {
+	fields := strings.Fields(s)
+	for _, field := range fields {
+		u, err := url.Parse(field)
+		if err != nil || u.User == nil {
+			continue
+		}
+		if _, ok := u.User.Password(); ok {
+			s = strings.Replace(s, field, u.Redacted(), 1)
+		}
+	}
+	return s
+}
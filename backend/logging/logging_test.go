@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Options{Level: slog.LevelInfo})
+	logger.Info("Connecting",
+		"password", "hunter2",
+		"client_secret", "s3cret",
+		"Authorization", "Bearer abc",
+		"database_url", "postgres://app:pw@db/app",
+		"target", "postgres://app:hunter3@db:5432/app?sslmode=disable",
+		"link", "https://example.com/verify?code=1",
+		"user_id", 7,
+	)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	for _, key := range []string{"password", "client_secret", "Authorization", "database_url"} {
+		if entry[key] != redacted {
+			t.Errorf("%s = %v, want %s", key, entry[key], redacted)
+		}
+	}
+	if target, _ := entry["target"].(string); strings.Contains(target, "hunter3") || !strings.Contains(target, "db:5432") {
+		t.Errorf("target = %q, want the URL without its password", target)
+	}
+	if entry["link"] != "https://example.com/verify?code=1" {
+		t.Errorf("link = %v, want it unchanged", entry["link"])
+	}
+	if entry["user_id"] != float64(7) {
+		t.Errorf("user_id = %v, want 7", entry["user_id"])
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != slog.Default() {
+		t.Error("FromContext without a logger should return the default logger")
+	}
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if got := FromContext(WithContext(context.Background(), logger)); got != logger {
+		t.Error("FromContext should return the logger WithContext stored")
+	}
+}
+
+func TestNewFormat(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, Options{Format: "text"}).Info("Hello", "n", 1)
+	if got := buf.String(); !strings.Contains(got, "msg=Hello n=1") {
+		t.Errorf("text output = %q", got)
+	}
+
+	buf.Reset()
+	New(&buf, Options{Level: slog.LevelWarn}).Info("Hidden")
+	if buf.Len() != 0 {
+		t.Errorf("logged below the level: %q", buf.String())
+	}
+}
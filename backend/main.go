@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"instagram-backend/app"
 	"instagram-backend/config"
+	"instagram-backend/jobs"
+	"instagram-backend/logging"
 	"instagram-backend/router"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,45 +25,75 @@ func init() {
 }
 
 func main() {
-
-	// Setup database
-	if err := config.SetupDatabase(); err != nil {
-		log.Fatalf("Failed to setup database: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	// Setup Redis
-	if err := config.SetupRedis(); err != nil {
-		log.Fatalf("Failed to setup Redis: %v", err)
+	// Load the configuration, stopping on anything wrong with it
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
 	}
-	defer config.CloseRedis()
 
-	// Setup router
-	router := router.SetupRouter()
+	// Log as JSON from here on, the standard logger included
+	logger := logging.New(os.Stdout, cfg.LogOptions())
+	slog.SetDefault(logger)
 
-	// Configure port
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Connect to the database and Redis and build the handlers
+	application, err := app.New(cfg)
+	if err != nil {
+		fatal("Failed to setup application", err)
 	}
+	defer application.Close()
+
+	// Keep the JWT signing keys rotating in the background
+	keysCtx, stopKeys := context.WithCancel(logging.WithContext(context.Background(), logger))
+	defer stopKeys()
+	go application.Keys.Run(keysCtx, time.Minute)
+
+	// Purge accounts past their deletion grace period, expired data exports,
+	// trash past its restore window and audit events past their retention
+	jobsCtx, stopJobs := context.WithCancel(logging.WithContext(context.Background(), logger))
+	defer stopJobs()
+	go jobs.RunPeriodically(jobsCtx, "account purge", time.Hour, func(ctx context.Context) error {
+		return jobs.PurgeDeletedAccounts(ctx, application.DB, application.Cache)
+	})
+	go jobs.RunPeriodically(jobsCtx, "export cleanup", time.Hour, func(ctx context.Context) error {
+		return jobs.PurgeExpiredExports(ctx, application.DB)
+	})
+	go jobs.RunPeriodically(jobsCtx, "trash purge", time.Hour, func(ctx context.Context) error {
+		return jobs.PurgeExpiredTrash(ctx, application.DB, cfg.Retention.Trash)
+	})
+	go jobs.RunPeriodically(jobsCtx, "audit retention", 24*time.Hour, func(ctx context.Context) error {
+		return jobs.PurgeExpiredAuditEvents(ctx, application.DB, cfg.Retention.Audit)
+	})
 
-	readTimeout, _ := time.ParseDuration(os.Getenv("READ_TIMEOUT"))
-	writeTimeout, _ := time.ParseDuration(os.Getenv("WRITE_TIMEOUT"))
-	idleTimeout, _ := time.ParseDuration(os.Getenv("IDLE_TIMEOUT"))
+	// Setup router
+	router := router.SetupRouter(application)
 
 	// Create server with timeouts
 	srv := &http.Server{
-		Addr:         ":" + port,
+		Addr:         ":" + cfg.Server.Port,
 		Handler:      router,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
-		IdleTimeout:  idleTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on http://localhost:%s", port)
+		slog.Info("Server starting", "addr", srv.Addr, "environment", cfg.Environment)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal("Failed to start server", err)
 		}
 	}()
 
@@ -69,18 +103,19 @@ func main() {
 	<-quit
 
 	// Graceful shutdown
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", err)
 	}
 
-	log.Println("Server exiting")
+	slog.Info("Server exiting")
+}
 
-	// Close database connection
-	if sqlDB, err := config.Db.DB(); err == nil {
-		sqlDB.Close()
-	}
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
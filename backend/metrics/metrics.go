@@ -0,0 +1,139 @@
+// Package metrics collects the application's Prometheus metrics: request
+// latency per route, cache hit ratios, database and Redis connection pools,
+// and counts of registrations, posts and likes.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Caches, for CacheLookup.
+const (
+	CachePost      = "post"
+	CacheUser      = "user"
+	CachePostsPage = "posts_page"
+)
+
+// Metrics records measurements in its own registry, so each App, including
+// those built by tests, has its own. A nil *Metrics records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requestDuration *prometheus.HistogramVec
+	cacheLookups    *prometheus.CounterVec
+	registrations   *prometheus.CounterVec
+	postsCreated    prometheus.Counter
+	likes           prometheus.Counter
+}
+
+// New returns Metrics with the Go runtime and process collectors registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to handle HTTP requests, by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_lookups_total",
+			Help: "Cache lookups, by cache and whether they hit.",
+		}, []string{"cache", "result"}),
+		registrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "registrations_total",
+			Help: "Accounts created, by sign-up method.",
+		}, []string{"method"}),
+		postsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "posts_created_total",
+			Help: "Posts published.",
+		}),
+		likes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "likes_total",
+			Help: "Posts liked.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.cacheLookups,
+		m.registrations,
+		m.postsCreated,
+		m.likes,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Register adds collectors, such as DBStats and RedisPool, to the registry.
+func (m *Metrics) Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DBStats collects the connection pool statistics of db as go_sql_*
+// metrics labelled with name.
+func DBStats(db *sql.DB, name string) prometheus.Collector {
+	return collectors.NewDBStatsCollector(db, name)
+}
+
+// ObserveRequest records a handled request. route is the route's pattern, not
+// the requested path, to keep the number of series bounded.
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+// CacheLookup records whether a lookup in cache found an entry.
+func (m *Metrics) CacheLookup(cache string, hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// Registered counts an account created through method, "password" or an
+// identity provider's name.
+func (m *Metrics) Registered(method string) {
+	if m == nil {
+		return
+	}
+	m.registrations.WithLabelValues(method).Inc()
+}
+
+// PostCreated counts a published post.
+func (m *Metrics) PostCreated() {
+	if m == nil {
+		return
+	}
+	m.postsCreated.Inc()
+}
+
+// Liked counts a like.
+func (m *Metrics) Liked() {
+	if m == nil {
+		return
+	}
+	m.likes.Inc()
+}
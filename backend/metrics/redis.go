@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	redisHitsDesc = prometheus.NewDesc("redis_pool_hits_total",
+		"Times a free connection was found in the pool.", nil, nil)
+	redisMissesDesc = prometheus.NewDesc("redis_pool_misses_total",
+		"Times no free connection was found in the pool.", nil, nil)
+	redisTimeoutsDesc = prometheus.NewDesc("redis_pool_timeouts_total",
+		"Times waiting for a connection timed out.", nil, nil)
+	redisTotalConnsDesc = prometheus.NewDesc("redis_pool_connections",
+		"Connections in the pool.", nil, nil)
+	redisIdleConnsDesc = prometheus.NewDesc("redis_pool_idle_connections",
+		"Idle connections in the pool.", nil, nil)
+	redisStaleConnsDesc = prometheus.NewDesc("redis_pool_stale_connections_total",
+		"Stale connections removed from the pool.", nil, nil)
+)
+
+type redisPoolCollector struct {
+	client *redis.Client
+}
+
+// RedisPool collects the connection pool statistics of client.
+func RedisPool(client *redis.Client) prometheus.Collector {
+	return redisPoolCollector{client: client}
+}
+
+func (c redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHitsDesc
+	ch <- redisMissesDesc
+	ch <- redisTimeoutsDesc
+	ch <- redisTotalConnsDesc
+	ch <- redisIdleConnsDesc
+	ch <- redisStaleConnsDesc
+}
+
+func (c redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeoutsDesc, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotalConnsDesc, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdleConnsDesc, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisStaleConnsDesc, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -1,17 +1,35 @@
 package middleware
 
 import (
+	"instagram-backend/keys"
+	"instagram-backend/models"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// lastSeenInterval limits how often a session's last-seen time is written.
+const lastSeenInterval = time.Minute
+
+// AuthMiddleware authenticates a request by its Bearer token or, for seller
+// integrations, by an X-API-Key header.
+func AuthMiddleware(db *gorm.DB, ks *keys.KeySet) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" && authHeader == "" {
+			if !authenticateAPIKey(c, db, apiKey) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
@@ -19,9 +37,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		token, err := ks.Parse(tokenString)
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -29,15 +45,48 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Purpose tokens (email verification, MFA challenges) are not logins.
 		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok || claims["purpose"] != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
+
+		userID, ok := claims["user_id"].(float64)
 		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			c.Abort()
 			return
 		}
+		sessionID, ok := claims["sid"].(float64)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
+
+		// The token is only as good as the session behind it.
+		var session models.Session
+		if err := db.WithContext(c.Request.Context()).Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?",
+			uint(sessionID), uint(userID), time.Now()).First(&session).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired or signed out"})
+			c.Abort()
+			return
+		}
 
-		userID := uint(claims["user_id"].(float64))
-		c.Set("user_id", userID)
+		if time.Since(session.LastSeenAt) > lastSeenInterval {
+			db.WithContext(c.Request.Context()).Model(&session).UpdateColumns(map[string]interface{}{
+				"last_seen_at": time.Now(),
+				"ip_address":   c.ClientIP(),
+			})
+		}
+
+		c.Set("user_id", uint(userID))
+		c.Set("session_id", session.ID)
+		if session.ImpersonatorID != nil {
+			c.Set("impersonator_id", *session.ImpersonatorID)
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"instagram-backend/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RejectSuspended stops suspended users at the door. Suspended users can still
+// sign in so they can read their notices and appeal, which live on routes
+// without this middleware. It must run after AuthMiddleware.
+func RejectSuspended(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := db.WithContext(c.Request.Context()).Select("id", "suspended_until").First(&user, c.GetUint("user_id")).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+
+		if user.SuspendedUntil != nil && time.Now().Before(*user.SuspendedUntil) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":          "Your account is suspended",
+				"suspendedUntil": user.SuspendedUntil,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
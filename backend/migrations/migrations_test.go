@@ -0,0 +1,188 @@
+package migrations_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"instagram-backend/migrations"
+	"instagram-backend/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// column is what the comparison checks about each column.
+type column struct {
+	Table    string
+	Name     string
+	DataType string
+	Nullable string
+}
+
+func (c column) String() string {
+	return fmt.Sprintf("%s.%s %s nullable=%s", c.Table, c.Name, c.DataType, c.Nullable)
+}
+
+// postgresDB returns a connection to TEST_DATABASE_URL that works in a new
+// schema of its own, dropped when t ends. The test is skipped without it.
+func postgresDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin := open(t, url)
+	schema := fmt.Sprintf("%s_%d", name, time.Now().UnixNano())
+	if err := admin.Exec(`CREATE SCHEMA "` + schema + `"`).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(`DROP SCHEMA "` + schema + `" CASCADE`)
+	})
+
+	if strings.Contains(url, "://") {
+		sep := "?"
+		if strings.Contains(url, "?") {
+			sep = "&"
+		}
+		return open(t, url+sep+"search_path="+schema)
+	}
+	return open(t, url+" search_path="+schema)
+}
+
+func open(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// columns lists the columns of the tables in db's schema, leaving out the
+// migrations' own bookkeeping.
+func columns(t *testing.T, db *gorm.DB) []column {
+	t.Helper()
+	var cols []column
+	if err := db.Raw(`SELECT table_name AS "table", column_name AS name, data_type, is_nullable AS nullable
+		FROM information_schema.columns
+		WHERE table_schema = CURRENT_SCHEMA() AND table_name <> 'schema_migrations'
+		ORDER BY table_name, column_name`).Scan(&cols).Error; err != nil {
+		t.Fatalf("list columns: %v", err)
+	}
+	return cols
+}
+
+// indexes lists the names of the indexes in db's schema.
+func indexes(t *testing.T, db *gorm.DB) map[string]bool {
+	t.Helper()
+	var names []string
+	if err := db.Raw(`SELECT indexname FROM pg_indexes
+		WHERE schemaname = CURRENT_SCHEMA() AND tablename <> 'schema_migrations'`).Scan(&names).Error; err != nil {
+		t.Fatalf("list indexes: %v", err)
+	}
+	set := map[string]bool{}
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// TestMigrationsMatchModels applies the SQL migrations to one schema and
+// AutoMigrates the models into another, and checks they agree.
+func TestMigrationsMatchModels(t *testing.T) {
+	migrated := postgresDB(t, "migrated")
+	automigrated := postgresDB(t, "automigrated")
+	ctx := context.Background()
+
+	migrator, err := migrations.New(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := automigrated.AutoMigrate(models.All()...); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	want := map[string]column{}
+	for _, c := range columns(t, automigrated) {
+		want[c.Table+"."+c.Name] = c
+	}
+	got := map[string]column{}
+	for _, c := range columns(t, migrated) {
+		got[c.Table+"."+c.Name] = c
+	}
+
+	var diffs []string
+	for key, w := range want {
+		g, ok := got[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, "missing from migrations: "+w.String())
+		case g != w:
+			diffs = append(diffs, fmt.Sprintf("migrations have %s, models want %s", g, w))
+		}
+	}
+	for key, g := range got {
+		if _, ok := want[key]; !ok {
+			diffs = append(diffs, "not in models: "+g.String())
+		}
+	}
+
+	// Migrations may add indexes the models don't declare, such as partial
+	// ones, but every index the models declare has to exist.
+	migratedIndexes := indexes(t, migrated)
+	for name := range indexes(t, automigrated) {
+		if !migratedIndexes[name] {
+			diffs = append(diffs, "index missing from migrations: "+name)
+		}
+	}
+
+	sort.Strings(diffs)
+	for _, diff := range diffs {
+		t.Error(diff)
+	}
+}
+
+// TestMigrationsRevert applies every migration, reverts them all and applies
+// them again.
+func TestMigrationsRevert(t *testing.T) {
+	db := postgresDB(t, "revert")
+	ctx := context.Background()
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := migrations.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := migrator.Up(ctx); err != nil || n != len(all) {
+		t.Fatalf("up = %d, %v; want %d", n, err, len(all))
+	}
+	if n, err := migrator.Down(ctx, len(all)); err != nil || n != len(all) {
+		t.Fatalf("down = %d, %v; want %d", n, err, len(all))
+	}
+	if cols := columns(t, db); len(cols) > 0 {
+		t.Errorf("reverting left %d columns behind, e.g. %s", len(cols), cols[0])
+	}
+	if n, err := migrator.Up(ctx); err != nil || n != len(all) {
+		t.Fatalf("up again = %d, %v; want %d", n, err, len(all))
+	}
+}
@@ -0,0 +1,23 @@
+DROP TABLE IF EXISTS "verification_requests";
+DROP TABLE IF EXISTS "audit_events";
+DROP TABLE IF EXISTS "blocked_keywords";
+DROP TABLE IF EXISTS "notices";
+DROP TABLE IF EXISTS "reports";
+DROP TABLE IF EXISTS "mutes";
+DROP TABLE IF EXISTS "blocks";
+DROP TABLE IF EXISTS "username_redirects";
+DROP TABLE IF EXISTS "categories";
+DROP TABLE IF EXISTS "data_exports";
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "signing_keys";
+DROP TABLE IF EXISTS "sessions";
+DROP TABLE IF EXISTS "external_identities";
+DROP TABLE IF EXISTS "settings";
+DROP TABLE IF EXISTS "recovery_codes";
+DROP TABLE IF EXISTS "subscriptions";
+DROP TABLE IF EXISTS "purchase_options";
+DROP TABLE IF EXISTS "post_images";
+DROP TABLE IF EXISTS "likes";
+DROP TABLE IF EXISTS "comments";
+DROP TABLE IF EXISTS "posts";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,381 @@
+-- Baseline: the schema AutoMigrate built before versioned migrations.
+-- Everything is created only if missing, so databases AutoMigrate already
+-- set up adopt this migration without changes.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "username" text NOT NULL,
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    "name" text,
+    "bio" text,
+    "profile_image" text,
+    "role" text NOT NULL,
+    "is_private" boolean NOT NULL DEFAULT false,
+    "category_id" bigint,
+    "username_changed_at" timestamptz,
+    "email_verified" boolean NOT NULL DEFAULT false,
+    "email_verified_at" timestamptz,
+    "verification_sent_at" timestamptz,
+    "totp_secret" text,
+    "totp_enabled" boolean NOT NULL DEFAULT false,
+    "suspended_until" timestamptz,
+    "warning_count" bigint NOT NULL DEFAULT 0,
+    "verified" boolean NOT NULL DEFAULT false,
+    "verified_at" timestamptz,
+    "deletion_scheduled_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_category_id" ON "users" ("category_id");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_users_deletion_scheduled_at" ON "users" ("deletion_scheduled_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+
+CREATE TABLE IF NOT EXISTS "posts" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "caption" text,
+    "video_url" text,
+    "live_stream_url" text,
+    "user_id" bigint,
+    "content_type" text,
+    "location" text,
+    "moderation_status" text NOT NULL DEFAULT '',
+    "deleted_by_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_posts" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_posts_moderation_status" ON "posts" ("moderation_status");
+
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "content" text,
+    "user_id" bigint,
+    "post_id" bigint,
+    "moderation_status" text NOT NULL DEFAULT '',
+    "status" text NOT NULL DEFAULT 'published',
+    "filter_reason" text,
+    "deleted_by_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_comments_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_posts_comments" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_comments_moderation_status" ON "comments" ("moderation_status");
+CREATE INDEX IF NOT EXISTS "idx_comments_status" ON "comments" ("status");
+
+CREATE TABLE IF NOT EXISTS "likes" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "post_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_likes_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_posts_likes" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_likes_deleted_at" ON "likes" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "post_images" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "post_id" bigint,
+    "image_url" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_post_images" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_images_deleted_at" ON "post_images" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "purchase_options" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "post_id" bigint,
+    "platform" text,
+    "url" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_posts_purchase_options" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_purchase_options_deleted_at" ON "purchase_options" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "subscriptions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "subscriber_id" bigint,
+    "seller_id" bigint,
+    "status" text NOT NULL DEFAULT 'approved',
+    "approved_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_subscriptions" FOREIGN KEY ("subscriber_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_users_subscribers" FOREIGN KEY ("seller_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_subscriptions_deleted_at" ON "subscriptions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_subscriptions_status" ON "subscriptions" ("status");
+
+CREATE TABLE IF NOT EXISTS "recovery_codes" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "code_hash" text NOT NULL,
+    "used_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_deleted_at" ON "recovery_codes" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "settings" (
+    "key" text,
+    "value" text NOT NULL,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("key")
+);
+
+CREATE TABLE IF NOT EXISTS "external_identities" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "provider" text NOT NULL,
+    "subject" text NOT NULL,
+    "email" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_external_identities_deleted_at" ON "external_identities" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_external_identities_user_id" ON "external_identities" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_external_identity" ON "external_identities" ("provider","subject");
+
+CREATE TABLE IF NOT EXISTS "sessions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "device_name" text,
+    "user_agent" text,
+    "ip_address" text,
+    "last_seen_at" timestamptz,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "impersonator_id" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_sessions_deleted_at" ON "sessions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_sessions_impersonator_id" ON "sessions" ("impersonator_id");
+CREATE INDEX IF NOT EXISTS "idx_sessions_revoked_at" ON "sessions" ("revoked_at");
+CREATE INDEX IF NOT EXISTS "idx_sessions_user_id" ON "sessions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "signing_keys" (
+    "id" text,
+    "algorithm" text NOT NULL,
+    "private_key" bytea NOT NULL,
+    "public_key" bytea NOT NULL,
+    "activates_at" timestamptz NOT NULL,
+    "retires_at" timestamptz NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_signing_keys_expires_at" ON "signing_keys" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "name" text NOT NULL,
+    "prefix" text NOT NULL,
+    "key_hash" text NOT NULL,
+    "scopes" text NOT NULL,
+    "last_used_at" timestamptz,
+    "last_used_ip" text,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_api_keys_deleted_at" ON "api_keys" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_api_keys_user_id" ON "api_keys" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+
+CREATE TABLE IF NOT EXISTS "data_exports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "status" text NOT NULL,
+    "file_path" text,
+    "completed_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_data_exports_deleted_at" ON "data_exports" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_data_exports_user_id" ON "data_exports" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "categories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "slug" text NOT NULL,
+    "name" text NOT NULL,
+    "description" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_categories_deleted_at" ON "categories" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_categories_slug" ON "categories" ("slug");
+
+CREATE TABLE IF NOT EXISTS "username_redirects" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "old_username" text NOT NULL,
+    "user_id" bigint NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_username_redirects_deleted_at" ON "username_redirects" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_username_redirects_user_id" ON "username_redirects" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_username_redirects_old_username" ON "username_redirects" ("old_username");
+
+CREATE TABLE IF NOT EXISTS "blocks" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "blocker_id" bigint NOT NULL,
+    "blocked_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_blocks_blocked" FOREIGN KEY ("blocked_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_blocks_blocked_id" ON "blocks" ("blocked_id");
+CREATE INDEX IF NOT EXISTS "idx_blocks_deleted_at" ON "blocks" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_block" ON "blocks" ("blocker_id","blocked_id");
+
+CREATE TABLE IF NOT EXISTS "mutes" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "muter_id" bigint NOT NULL,
+    "muted_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_mutes_muted" FOREIGN KEY ("muted_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_mutes_deleted_at" ON "mutes" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_mute" ON "mutes" ("muter_id","muted_id");
+
+CREATE TABLE IF NOT EXISTS "reports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "reporter_id" bigint NOT NULL,
+    "target_type" text NOT NULL,
+    "target_id" bigint NOT NULL,
+    "reason" text NOT NULL,
+    "details" text,
+    "status" text NOT NULL DEFAULT 'open',
+    "assignee_id" bigint,
+    "action" text,
+    "resolved_by_id" bigint,
+    "resolved_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_reports_assignee_id" ON "reports" ("assignee_id");
+CREATE INDEX IF NOT EXISTS "idx_reports_deleted_at" ON "reports" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_reports_status" ON "reports" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_report_target" ON "reports" ("reporter_id","target_type","target_id");
+
+CREATE TABLE IF NOT EXISTS "notices" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "report_id" bigint,
+    "target_type" text,
+    "target_id" bigint,
+    "action" text NOT NULL,
+    "message" text,
+    "appeal_text" text,
+    "appeal_status" text,
+    "appealed_at" timestamptz,
+    "read_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notices_appeal_status" ON "notices" ("appeal_status");
+CREATE INDEX IF NOT EXISTS "idx_notices_deleted_at" ON "notices" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_notices_user_id" ON "notices" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "blocked_keywords" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "seller_id" bigint NOT NULL,
+    "keyword" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_blocked_keywords_deleted_at" ON "blocked_keywords" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_blocked_keyword" ON "blocked_keywords" ("seller_id","keyword");
+
+CREATE TABLE IF NOT EXISTS "audit_events" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "actor_id" bigint,
+    "action" text NOT NULL,
+    "target_type" text,
+    "target_id" bigint,
+    "details" text,
+    "impersonator_id" bigint,
+    "request_id" text,
+    "ip_address" text,
+    "changes" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_events_action" ON "audit_events" ("action");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_actor_id" ON "audit_events" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_created_at" ON "audit_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_request_id" ON "audit_events" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_target" ON "audit_events" ("target_type","target_id");
+
+CREATE TABLE IF NOT EXISTS "verification_requests" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "legal_name" text NOT NULL,
+    "website" text,
+    "documents" text,
+    "notes" text,
+    "status" text NOT NULL DEFAULT 'pending',
+    "reviewer_id" bigint,
+    "reason" text,
+    "reviewed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_verification_requests_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_verification_requests_deleted_at" ON "verification_requests" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_verification_requests_status" ON "verification_requests" ("status");
+CREATE INDEX IF NOT EXISTS "idx_verification_requests_user_id" ON "verification_requests" ("user_id");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_comments_trash";
+DROP INDEX IF EXISTS "idx_posts_trash";
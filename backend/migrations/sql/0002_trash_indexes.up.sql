@@ -0,0 +1,4 @@
+-- The trash listings only look at deleted rows, which are few, so index just
+-- those.
+CREATE INDEX IF NOT EXISTS "idx_posts_trash" ON "posts" ("deleted_by_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_comments_trash" ON "comments" ("deleted_by_id", "deleted_at") WHERE "deleted_at" IS NOT NULL;
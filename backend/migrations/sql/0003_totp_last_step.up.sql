@@ -0,0 +1,3 @@
+-- The time step of the last TOTP code each user logged in with, so a code
+-- can't be replayed within its window.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_last_step" bigint;
@@ -0,0 +1,4 @@
+-- Submitting a verification request checks for a pending one first, but two
+-- concurrent submissions can both pass that check, so let the database refuse
+-- the second.
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_verification_requests_pending" ON "verification_requests" ("user_id") WHERE "status" = 'pending' AND "deleted_at" IS NULL;
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,7 +16,31 @@ type User struct {
 	Bio          string `json:"bio"`
 	ProfileImage string `json:"profileImage"`
 	Role         string `gorm:"not null" json:"role"` // "seller" or "buyer"
-	Posts        []Post `gorm:"foreignKey:UserID" json:"posts,omitempty"`
+	// IsPrivate accounts only show their posts to approved subscribers.
+	IsPrivate bool `gorm:"not null;default:false" json:"isPrivate"`
+	// CategoryID is the storefront category a seller's username is namespaced under.
+	CategoryID        *uint      `gorm:"index" json:"categoryId,omitempty"`
+	UsernameChangedAt *time.Time `json:"-"`
+	// EmailVerified is set once the user follows the link sent at registration.
+	EmailVerified      bool       `gorm:"not null;default:false" json:"emailVerified"`
+	EmailVerifiedAt    *time.Time `json:"emailVerifiedAt,omitempty"`
+	VerificationSentAt *time.Time `json:"-"`
+	// TOTPSecret holds the authenticator secret; TOTPEnabled is only set once
+	// the user has confirmed enrollment with a valid code. TOTPLastStep is the
+	// time step of the last code accepted, which can't be used again.
+	TOTPSecret   string `json:"-"`
+	TOTPEnabled  bool   `gorm:"not null;default:false" json:"totpEnabled"`
+	TOTPLastStep *int64 `json:"-"`
+	// SuspendedUntil blocks everything but reading notices and appealing them.
+	SuspendedUntil *time.Time `json:"suspendedUntil,omitempty"`
+	WarningCount   int        `gorm:"not null;default:0" json:"warningCount"`
+	// Verified is the badge shown on sellers whose identity an admin has
+	// confirmed through a VerificationRequest.
+	Verified   bool       `gorm:"not null;default:false" json:"verified"`
+	VerifiedAt *time.Time `json:"verifiedAt,omitempty"`
+	// DeletionScheduledAt is when a requested account deletion will be purged.
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletionScheduledAt,omitempty"`
+	Posts               []Post     `gorm:"foreignKey:UserID" json:"posts,omitempty"`
 	// For buyers: the sellers they subscribe to
 	Subscriptions []Subscription `gorm:"foreignKey:SubscriberID" json:"subscriptions,omitempty"`
 	// For sellers: the list of subscribers who follow them
@@ -24,7 +49,24 @@ type User struct {
 	UpdatedAt   time.Time      `json:"updatedAt"`
 }
 
-// username for sellers should be assigned as seller_work/sellername e.g., "clothers/tosif"
+// username for sellers should be assigned as seller_work/sellername e.g., "clothers/tosif",
+// where seller_work is the slug of a Category. Buyer usernames have no prefix.
+
+// Category is a storefront category; its slug prefixes seller usernames.
+type Category struct {
+	gorm.Model
+	Slug        string `gorm:"uniqueIndex;not null" json:"slug"`
+	Name        string `gorm:"not null" json:"name"`
+	Description string `json:"description"`
+}
+
+// UsernameRedirect keeps a user's previous username pointing at them after a
+// rename, and stops anyone else from claiming it.
+type UsernameRedirect struct {
+	gorm.Model
+	OldUsername string `gorm:"uniqueIndex;not null" json:"oldUsername"`
+	UserID      uint   `gorm:"index;not null" json:"userId"`
+}
 
 type Post struct {
 	gorm.Model
@@ -43,8 +85,23 @@ type Post struct {
 	Location        string           `json:"location,omitempty"`
 	CreatedAt       time.Time        `json:"createdAt"`
 	UpdatedAt       time.Time        `json:"updatedAt"`
+	// ModerationStatus is empty for visible posts; see the Moderation* constants.
+	ModerationStatus string `gorm:"not null;default:'';index" json:"moderationStatus,omitempty"`
+	// DeletedByID is who deleted the post. Only posts their author deleted
+	// show up in the author's trash.
+	DeletedByID *uint `json:"deletedById,omitempty"`
 }
 
+// Moderation statuses for posts and comments.
+const (
+	// ModerationHidden is applied automatically once reports cross the
+	// threshold, until a moderator reviews the content.
+	ModerationHidden = "hidden"
+	// ModerationShadowHidden content is still shown to its author.
+	ModerationShadowHidden = "shadow_hidden"
+	ModerationRemoved      = "removed"
+)
+
 // PostImage represents a single image associated with a feed post.
 type PostImage struct {
 	gorm.Model
@@ -78,6 +135,29 @@ type Comment struct {
 	Post      Post      `json:"-"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// ModerationStatus is empty for visible comments; see the Moderation* constants.
+	ModerationStatus string `gorm:"not null;default:'';index" json:"moderationStatus,omitempty"`
+	// Status is "held" while a comment caught by the content filter awaits
+	// the seller's review; FilterReason says why it was caught.
+	Status       string `gorm:"not null;default:published;index" json:"status"`
+	FilterReason string `json:"filterReason,omitempty"`
+	// DeletedByID is who deleted the comment, which isn't set when it went
+	// along with its post.
+	DeletedByID *uint `json:"deletedById,omitempty"`
+}
+
+// Comment statuses.
+const (
+	CommentPublished = "published"
+	CommentHeld      = "held"
+)
+
+// BlockedKeyword is a word or phrase a seller doesn't want in comments on
+// their posts. Matching comments are held for review.
+type BlockedKeyword struct {
+	gorm.Model
+	SellerID uint   `gorm:"uniqueIndex:idx_blocked_keyword;not null" json:"sellerId"`
+	Keyword  string `gorm:"uniqueIndex:idx_blocked_keyword;not null" json:"keyword"`
 }
 
 // Subscription replaces the generic follow model, representing a buyer subscribing to a seller.
@@ -88,4 +168,246 @@ type Subscription struct {
 	Subscriber   User      `gorm:"foreignKey:SubscriberID" json:"subscriber"`
 	Seller       User      `gorm:"foreignKey:SellerID" json:"seller"`
 	CreatedAt    time.Time `json:"createdAt"`
+	// Status is "pending" until a private seller approves the request.
+	Status     string     `gorm:"not null;default:approved;index" json:"status"`
+	ApprovedAt *time.Time `json:"approvedAt,omitempty"`
+}
+
+// Subscription statuses.
+const (
+	SubscriptionPending  = "pending"
+	SubscriptionApproved = "approved"
+)
+
+// Block hides two users from each other entirely: neither can see the other's
+// profile or posts, comment, like or subscribe.
+type Block struct {
+	gorm.Model
+	BlockerID uint `gorm:"uniqueIndex:idx_block;not null" json:"blockerId"`
+	BlockedID uint `gorm:"uniqueIndex:idx_block;not null;index" json:"blockedId"`
+	Blocked   User `gorm:"foreignKey:BlockedID" json:"blocked"`
+}
+
+// Mute hides a user's posts from the muter's feed without them knowing.
+type Mute struct {
+	gorm.Model
+	MuterID uint `gorm:"uniqueIndex:idx_mute;not null" json:"muterId"`
+	MutedID uint `gorm:"uniqueIndex:idx_mute;not null" json:"mutedId"`
+	Muted   User `gorm:"foreignKey:MutedID" json:"muted"`
+}
+
+// Report is a user's complaint about a post, comment or account.
+type Report struct {
+	gorm.Model
+	ReporterID   uint       `gorm:"uniqueIndex:idx_report_target;not null" json:"reporterId"`
+	TargetType   string     `gorm:"uniqueIndex:idx_report_target;not null" json:"targetType"` // "post", "comment" or "user"
+	TargetID     uint       `gorm:"uniqueIndex:idx_report_target;not null" json:"targetId"`
+	Reason       string     `gorm:"not null" json:"reason"`
+	Details      string     `json:"details,omitempty"`
+	Status       string     `gorm:"not null;default:open;index" json:"status"` // "open", "assigned" or "resolved"
+	AssigneeID   *uint      `gorm:"index" json:"assigneeId,omitempty"`
+	Action       string     `json:"action,omitempty"` // what the moderator did when resolving
+	ResolvedByID *uint      `json:"resolvedById,omitempty"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// Report target types, reasons and statuses.
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+
+	ReportStatusOpen     = "open"
+	ReportStatusAssigned = "assigned"
+	ReportStatusResolved = "resolved"
+)
+
+// ReportReasons lists the reason codes a report may use.
+var ReportReasons = []string{"spam", "harassment", "hate_speech", "nudity", "violence", "scam", "counterfeit", "fake_storefront", "other"}
+
+// Notice tells a user about a moderation action taken against them. The user
+// may appeal it once.
+type Notice struct {
+	gorm.Model
+	UserID       uint       `gorm:"index;not null" json:"userId"`
+	ReportID     *uint      `json:"reportId,omitempty"`
+	TargetType   string     `json:"targetType,omitempty"`
+	TargetID     uint       `json:"targetId,omitempty"`
+	Action       string     `gorm:"not null" json:"action"`
+	Message      string     `json:"message"`
+	AppealText   string     `json:"appealText,omitempty"`
+	AppealStatus string     `gorm:"index" json:"appealStatus,omitempty"` // "pending", "upheld" or "overturned"
+	AppealedAt   *time.Time `json:"appealedAt,omitempty"`
+	ReadAt       *time.Time `json:"readAt,omitempty"`
+}
+
+// Appeal states on a notice.
+const (
+	AppealPending    = "pending"
+	AppealUpheld     = "upheld"
+	AppealOverturned = "overturned"
+)
+
+// RecoveryCode is a single-use fallback for TOTP, stored as a bcrypt hash.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"index;not null" json:"userId"`
+	CodeHash string     `gorm:"not null" json:"-"`
+	UsedAt   *time.Time `json:"usedAt,omitempty"`
+}
+
+// ExternalIdentity links a user to an account at an OpenID Connect provider.
+type ExternalIdentity struct {
+	gorm.Model
+	UserID   uint   `gorm:"index;not null" json:"userId"`
+	Provider string `gorm:"uniqueIndex:idx_external_identity;not null" json:"provider"`
+	Subject  string `gorm:"uniqueIndex:idx_external_identity;not null" json:"-"`
+	Email    string `json:"email"`
+}
+
+// Session is a signed-in device. Login tokens reference it through their "sid"
+// claim and stop working once it is revoked or expires.
+type Session struct {
+	gorm.Model
+	UserID     uint       `gorm:"index;not null" json:"userId"`
+	DeviceName string     `json:"deviceName"`
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RevokedAt  *time.Time `gorm:"index" json:"-"`
+	// ImpersonatorID is the admin who opened this session to act as the user.
+	ImpersonatorID *uint `gorm:"index" json:"impersonatorId,omitempty"`
+}
+
+// SigningKey is a JWT signing key. PrivateKey is AES-GCM encrypted PKCS#8;
+// PublicKey is PKIX DER.
+type SigningKey struct {
+	ID          string    `gorm:"primaryKey"`
+	Algorithm   string    `gorm:"not null"`
+	PrivateKey  []byte    `gorm:"not null"`
+	PublicKey   []byte    `gorm:"not null"`
+	ActivatesAt time.Time `gorm:"not null"`
+	RetiresAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"index;not null"`
+	CreatedAt   time.Time
+}
+
+// APIKey lets a seller's own tools call the API. Only a SHA-256 hash of the
+// key is stored; Prefix is kept so the owner can tell keys apart.
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `gorm:"index;not null" json:"userId"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"`
+	KeyHash    string     `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes     string     `gorm:"not null" json:"-"` // space separated
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// API key scopes.
+const (
+	ScopePostsWrite    = "posts:write"
+	ScopeProductsWrite = "products:write"
+)
+
+// APIKeyScopes lists every scope a key may be granted.
+var APIKeyScopes = []string{ScopePostsWrite, ScopeProductsWrite}
+
+// DataExport is an asynchronous archive of everything stored about a user.
+type DataExport struct {
+	gorm.Model
+	UserID      uint       `gorm:"index;not null" json:"userId"`
+	Status      string     `gorm:"not null" json:"status"` // "pending", "ready" or "failed"
+	FilePath    string     `json:"-"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Setting is an admin-controlled key/value switch.
+type Setting struct {
+	Key       string    `gorm:"primaryKey" json:"key"`
+	Value     string    `gorm:"not null" json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SettingRequireSellerTwoFactor forces sellers to enroll in TOTP before publishing.
+const SettingRequireSellerTwoFactor = "require_seller_2fa"
+
+// AuditEvent records a security-relevant or administrative action. Rows are
+// only ever inserted; see BeforeUpdate and BeforeDelete.
+type AuditEvent struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `gorm:"index" json:"createdAt"`
+	ActorID    uint      `gorm:"index" json:"actorId"`
+	Action     string    `gorm:"index;not null" json:"action"`
+	TargetType string    `gorm:"index:idx_audit_target" json:"targetType"`
+	TargetID   uint      `gorm:"index:idx_audit_target" json:"targetId"`
+	Details    JSONText  `gorm:"type:text" json:"details,omitempty"`
+	// ImpersonatorID is the admin acting through a support session, if any.
+	ImpersonatorID *uint  `json:"impersonatorId,omitempty"`
+	RequestID      string `gorm:"index" json:"requestId,omitempty"`
+	IPAddress      string `json:"ipAddress,omitempty"`
+	// Changes maps each changed field to its old and new value.
+	Changes JSONText `gorm:"type:text" json:"changes,omitempty"`
+}
+
+// JSONText is a JSON document stored as text and served as-is.
+type JSONText string
+
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+// ErrAuditAppendOnly is returned when code tries to modify an audit event.
+var ErrAuditAppendOnly = errors.New("audit events are append-only")
+
+func (AuditEvent) BeforeUpdate(*gorm.DB) error { return ErrAuditAppendOnly }
+
+func (AuditEvent) BeforeDelete(*gorm.DB) error { return ErrAuditAppendOnly }
+
+// VerificationRequest is a seller's application for the verified badge.
+type VerificationRequest struct {
+	gorm.Model
+	// A user may have only one pending request at a time.
+	UserID    uint   `gorm:"index;uniqueIndex:idx_verification_requests_pending,where:status = 'pending' AND deleted_at IS NULL;not null" json:"userId"`
+	User      User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	LegalName string `gorm:"not null" json:"legalName"`
+	Website   string `json:"website,omitempty"`
+	// Documents lists references to supporting documents, such as links to
+	// uploaded registration certificates, as a JSON array.
+	Documents  JSONText   `gorm:"type:text" json:"documents"`
+	Notes      string     `json:"notes,omitempty"`
+	Status     string     `gorm:"index;not null;default:pending" json:"status"`
+	ReviewerID *uint      `json:"reviewerId,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+}
+
+// Verification request states.
+const (
+	VerificationPending  = "pending"
+	VerificationApproved = "approved"
+	VerificationRejected = "rejected"
+)
+
+// All returns every model stored in the database, for building or checking
+// the schema.
+func All() []interface{} {
+	return []interface{}{
+		&User{}, &Category{}, &UsernameRedirect{},
+		&Post{}, &PostImage{}, &PurchaseOption{},
+		&Like{}, &Comment{}, &BlockedKeyword{},
+		&Subscription{}, &Block{}, &Mute{},
+		&Report{}, &Notice{},
+		&RecoveryCode{}, &ExternalIdentity{}, &Session{},
+		&SigningKey{}, &APIKey{}, &DataExport{},
+		&Setting{}, &AuditEvent{}, &VerificationRequest{},
+	}
 }
@@ -17,6 +17,8 @@ type SwaggerUser struct {
 	Bio          string           `json:"bio" example:"Software Developer"`
 	ProfileImage string           `json:"profileImage" example:"https://example.com/profile.jpg"`
 	Role         string           `json:"role" example:"seller"`
+	EmailVerified bool            `json:"emailVerified" example:"true"`
+	Verified     bool             `json:"verified" example:"false"`
 	Posts        []SwaggerPost    `json:"posts,omitempty"`
 	Subscribers  []SwaggerUser    `json:"subscribers,omitempty"`
 }
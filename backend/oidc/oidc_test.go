@@ -0,0 +1,124 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"instagram-backend/oidc"
+	"instagram-backend/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost/callback"
+
+func newProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+	mock := oidctest.NewProvider("client-id", "client-secret")
+	t.Cleanup(mock.Close)
+
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		Name:         "mock",
+		Issuer:       mock.Issuer(),
+		ClientID:     mock.ClientID,
+		ClientSecret: mock.ClientSecret,
+		RedirectURL:  redirectURL,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	return mock, provider
+}
+
+// authorize follows the redirect to the mock provider and returns the code it
+// sends back to the redirect URL.
+func authorize(t *testing.T, provider *oidc.Provider, req oidc.AuthRequest) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(provider.AuthCodeURL(req))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse callback: %v", err)
+	}
+	if got := callback.Query().Get("state"); got != req.State {
+		t.Fatalf("state = %q, want %q", got, req.State)
+	}
+	return callback.Query().Get("code")
+}
+
+func TestExchangeReturnsVerifiedIdentity(t *testing.T) {
+	mock, provider := newProvider(t)
+	mock.SetUser(oidctest.User{Subject: "sub-1", Email: "Jane@Example.com", EmailVerified: true, Name: "Jane"})
+
+	req := provider.NewAuthRequest()
+	identity, err := provider.Exchange(context.Background(), authorize(t, provider, req), req)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	want := oidc.Identity{Provider: "mock", Subject: "sub-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}
+	if *identity != want {
+		t.Fatalf("identity = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	_, provider := newProvider(t)
+
+	req := provider.NewAuthRequest()
+	code := authorize(t, provider, req)
+	req.Verifier = provider.NewAuthRequest().Verifier
+
+	if _, err := provider.Exchange(context.Background(), code, req); err == nil {
+		t.Fatal("Exchange succeeded with a mismatched PKCE verifier")
+	}
+}
+
+func TestExchangeRejectsWrongNonce(t *testing.T) {
+	_, provider := newProvider(t)
+
+	req := provider.NewAuthRequest()
+	code := authorize(t, provider, req)
+	req.Nonce = "other"
+
+	if _, err := provider.Exchange(context.Background(), code, req); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Fatalf("Exchange error = %v, want ErrNonceMismatch", err)
+	}
+}
+
+func TestRegistryUnknownProvider(t *testing.T) {
+	registry := oidc.NewRegistry()
+	if _, err := registry.Get(context.Background(), "google"); !errors.Is(err, oidc.ErrUnknownProvider) {
+		t.Fatalf("Get error = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestMemoryStateStoreTakeIsSingleUse(t *testing.T) {
+	store := oidc.NewMemoryStateStore()
+	ctx := context.Background()
+	req := oidc.AuthRequest{Provider: "mock", State: "abc"}
+
+	if err := store.Save(ctx, req, -time.Second); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := store.Take(ctx, "abc"); !errors.Is(err, oidc.ErrStateNotFound) {
+		t.Fatalf("expired Take error = %v, want ErrStateNotFound", err)
+	}
+
+	store.Save(ctx, req, time.Minute)
+	if got, err := store.Take(ctx, "abc"); err != nil || got != req {
+		t.Fatalf("Take = %+v, %v", got, err)
+	}
+	if _, err := store.Take(ctx, "abc"); !errors.Is(err, oidc.ErrStateNotFound) {
+		t.Fatalf("second Take error = %v, want ErrStateNotFound", err)
+	}
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrStateNotFound is returned for unknown, expired or already used state values.
+var ErrStateNotFound = errors.New("login state not found or expired")
+
+// StateStore keeps AuthRequests between the redirect and the callback. Take
+// must delete the entry so a state value can only be redeemed once.
+type StateStore interface {
+	Save(ctx context.Context, req AuthRequest, ttl time.Duration) error
+	Take(ctx context.Context, state string) (AuthRequest, error)
+}
+
+const stateKeyPrefix = "oidc_state:"
+
+// RedisStateStore shares login state between server instances.
+type RedisStateStore struct {
+	Client *redis.Client
+}
+
+func (s *RedisStateStore) Save(ctx context.Context, req AuthRequest, ttl time.Duration) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return s.Client.Set(ctx, stateKeyPrefix+req.State, data, ttl).Err()
+}
+
+func (s *RedisStateStore) Take(ctx context.Context, state string) (AuthRequest, error) {
+	var req AuthRequest
+	data, err := s.Client.GetDel(ctx, stateKeyPrefix+state).Bytes()
+	if err == redis.Nil {
+		return req, ErrStateNotFound
+	}
+	if err != nil {
+		return req, err
+	}
+	err = json.Unmarshal(data, &req)
+	return req, err
+}
+
+// MemoryStateStore keeps login state in process. It suits tests and single
+// instance deployments.
+type MemoryStateStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryState
+}
+
+type memoryState struct {
+	req       AuthRequest
+	expiresAt time.Time
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{entries: make(map[string]memoryState)}
+}
+
+func (s *MemoryStateStore) Save(ctx context.Context, req AuthRequest, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[req.State] = memoryState{req: req, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStateStore) Take(ctx context.Context, state string) (AuthRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[state]
+	delete(s.entries, state)
+	if !ok || time.Now().After(entry.expiresAt) {
+		return AuthRequest{}, ErrStateNotFound
+	}
+	return entry.req, nil
+}
@@ -0,0 +1,743 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewGorm returns repositories backed by db.
+func NewGorm(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Users:         &gormUsers{db: db},
+		Posts:         &gormPosts{db: db},
+		Comments:      &gormComments{db: db},
+		Likes:         &gormLikes{db: db},
+		Subscriptions: &gormSubscriptions{db: db},
+		Blocks:        &gormBlocks{db: db},
+		Sessions:      &gormSessions{db: db},
+		APIKeys:       &gormAPIKeys{db: db},
+		RecoveryCodes: &gormRecoveryCodes{db: db},
+		Identities:    &gormIdentities{db: db},
+		DataExports:   &gormDataExports{db: db},
+		Settings:      &gormSettings{db: db},
+		Categories:    &gormCategories{db: db},
+		Keywords:      &gormKeywords{db: db},
+		Verifications: &gormVerifications{db: db},
+		Reports:       &gormReports{db: db},
+		Notices:       &gormNotices{db: db},
+		Audit:         &gormAudit{db: db},
+		transaction: func(ctx context.Context, fn func(tx *Repositories) error) error {
+			return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				return fn(NewGorm(tx))
+			})
+		},
+	}
+}
+
+// notFound translates GORM's missing-record error.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r *gormUsers) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) FindByEmailIgnoreCase(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUsers) FindByUsername(ctx context.Context, username string) (*models.User, bool, error) {
+	db := r.db.WithContext(ctx)
+	var user models.User
+	err := db.Where("username = ?", username).First(&user).Error
+	if err == nil {
+		return &user, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	var redirect models.UsernameRedirect
+	if err := db.Where("old_username = ?", username).First(&redirect).Error; err != nil {
+		return nil, false, notFound(err)
+	}
+	if err := db.First(&user, redirect.UserID).Error; err != nil {
+		return nil, false, notFound(err)
+	}
+	return &user, true, nil
+}
+
+func (r *gormUsers) Exists(ctx context.Context, email, username string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("email = ? OR username = ?", email, username).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormUsers) UsernameAvailable(ctx context.Context, username string, userID uint) (bool, error) {
+	db := r.db.WithContext(ctx)
+	var count int64
+	if err := db.Model(&models.User{}).Where("username = ? AND id <> ?", username, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if err := db.Model(&models.UsernameRedirect{}).Where("old_username = ? AND user_id <> ?", username, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+func (r *gormUsers) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormUsers) Update(ctx context.Context, user *models.User, fields ...string) error {
+	return r.db.WithContext(ctx).Model(user).Select(fields).Updates(user).Error
+}
+
+func (r *gormUsers) Rename(ctx context.Context, userID uint, oldUsername, username string) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Unscoped().Where("old_username = ?", username).Delete(&models.UsernameRedirect{}).Error; err != nil {
+		return err
+	}
+	return db.Create(&models.UsernameRedirect{OldUsername: oldUsername, UserID: userID}).Error
+}
+
+func (r *gormUsers) CancelDeletion(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL", id).
+		Update("deletion_scheduled_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormUsers) RevokeVerification(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ? AND verified = ?", id, true).
+		Updates(map[string]interface{}{"verified": false, "verified_at": nil})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormUsers) AcceptTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormUsers) IsBlocked(ctx context.Context, a, b uint) (bool, error) {
+	if a == b {
+		return false, nil
+	}
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormUsers) ListSellers(ctx context.Context, categoryID uint, offset, limit int) ([]models.User, error) {
+	var sellers []models.User
+	err := r.db.WithContext(ctx).Where("category_id = ? AND role = ?", categoryID, "seller").
+		Order("username").Offset(offset).Limit(limit).Find(&sellers).Error
+	return sellers, err
+}
+
+func (r *gormUsers) Search(ctx context.Context, filter UserFilter, offset, limit int) ([]models.User, error) {
+	query := r.db.WithContext(ctx)
+	if q := strings.ToLower(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ? OR LOWER(name) LIKE ?", like, like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Suspended {
+		query = query.Where("suspended_until > ?", time.Now())
+	}
+	var users []models.User
+	err := query.Order("id").Offset(offset).Limit(limit).Find(&users).Error
+	return users, err
+}
+
+func (r *gormUsers) Suspend(ctx context.Context, id uint, until *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("suspended_until", until).Error
+}
+
+func (r *gormUsers) AddWarnings(ctx context.Context, id uint, delta int) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ? AND warning_count + ? >= 0", id, delta).
+		Update("warning_count", gorm.Expr("warning_count + ?", delta)).Error
+}
+
+func (r *gormUsers) BlockedWith(ctx context.Context, userID uint) ([]uint, error) {
+	var blocks []models.Block
+	if err := r.db.WithContext(ctx).Where("blocker_id = ? OR blocked_id = ?", userID, userID).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(blocks))
+	for _, b := range blocks {
+		if b.BlockerID == userID {
+			ids = append(ids, b.BlockedID)
+		} else {
+			ids = append(ids, b.BlockerID)
+		}
+	}
+	return ids, nil
+}
+
+type gormPosts struct {
+	db *gorm.DB
+}
+
+func (r *gormPosts) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) FindWithAuthor(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Preload("User").First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) FindWithMedia(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Preload("User").
+		Preload("PostImages").
+		Preload("PurchaseOptions").
+		First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) FindWithDeleted(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Unscoped().First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) FindWithDetails(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Preload("User").
+		Preload("Likes").
+		Preload("Comments", PublishedComments).
+		Preload("Comments.User").
+		Preload("PostImages").
+		Preload("PurchaseOptions").
+		First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) Feed(ctx context.Context, viewerID uint, offset, limit int) ([]models.Post, int64, error) {
+	db := r.db.WithContext(ctx)
+
+	// Count and fetch in parallel
+	totalChan := make(chan int64, 1)
+	errorChan := make(chan error, 2)
+	go func() {
+		var total int64
+		if err := db.Model(&models.Post{}).Scopes(VisiblePosts(viewerID), NotMuted(viewerID)).Count(&total).Error; err != nil {
+			errorChan <- err
+			return
+		}
+		totalChan <- total
+	}()
+
+	var posts []models.Post
+	if err := db.Preload("User").
+		Preload("Likes").
+		Preload("Comments", PublishedComments).
+		Preload("PostImages").
+		Preload("PurchaseOptions").
+		Scopes(VisiblePosts(viewerID), NotMuted(viewerID)).
+		Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&posts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	select {
+	case err := <-errorChan:
+		return nil, 0, err
+	case total := <-totalChan:
+		return posts, total, nil
+	}
+}
+
+func (r *gormPosts) Create(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
+}
+
+func (r *gormPosts) Update(ctx context.Context, post *models.Post, fields ...string) error {
+	return r.db.WithContext(ctx).Model(post).Select(fields).Updates(post).Error
+}
+
+func (r *gormPosts) SetModerationStatus(ctx context.Context, id uint, status string) error {
+	return r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).Update("moderation_status", status).Error
+}
+
+func (r *gormPosts) IDsByAuthor(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.Post{}).Where("user_id = ?", userID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *gormPosts) ListPublished(ctx context.Context, userID uint, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.WithContext(ctx).Preload("PostImages").Where("user_id = ? AND moderation_status = ?", userID, "").
+		Order("created_at desc").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+func (r *gormPosts) CountPublished(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("user_id = ? AND moderation_status = ?", userID, "").Count(&count).Error
+	return count, err
+}
+
+// postChildren are the rows deleted and restored along with a post.
+var postChildren = []interface{}{&models.PostImage{}, &models.PurchaseOption{}, &models.Like{}, &models.Comment{}}
+
+func (r *gormPosts) Trash(ctx context.Context, id, deletedBy uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.Post{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": now, "deleted_by_id": deletedBy})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		for _, model := range postChildren {
+			if err := tx.Model(model).Where("post_id = ?", id).Update("deleted_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *gormPosts) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deletedAt := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.Post{}).
+			Select("deleted_at").Where("id = ?", id)
+		for _, model := range postChildren {
+			if err := tx.Unscoped().Model(model).Where("post_id = ? AND deleted_at = (?)", id, deletedAt).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().Model(&models.Post{}).Where("id = ? AND deleted_at IS NOT NULL", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "deleted_by_id": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *gormPosts) FindTrashed(ctx context.Context, id, userID uint, since time.Time) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.WithContext(ctx).Scopes(Trashed(userID, since)).First(&post, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPosts) ListTrashed(ctx context.Context, userID uint, since time.Time) ([]models.Post, error) {
+	deletedWithPost := func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where("deleted_at = (SELECT deleted_at FROM posts WHERE posts.id = post_id)")
+	}
+	var posts []models.Post
+	err := r.db.WithContext(ctx).Scopes(Trashed(userID, since)).
+		Preload("PostImages", deletedWithPost).
+		Preload("PurchaseOptions", deletedWithPost).
+		Order("deleted_at desc").Find(&posts).Error
+	return posts, err
+}
+
+type gormComments struct {
+	db *gorm.DB
+}
+
+func (r *gormComments) ListVisible(ctx context.Context, postID, viewerID uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("post_id = ?", postID).
+		Scopes(VisibleComments(viewerID)).
+		Order("created_at desc").
+		Find(&comments).Error
+	return comments, err
+}
+
+func (r *gormComments) Create(ctx context.Context, comment *models.Comment) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Create(comment).Error; err != nil {
+		return err
+	}
+	return db.Preload("User").First(comment, comment.ID).Error
+}
+
+func (r *gormComments) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormComments) FindWithAuthor(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Preload("User").First(&comment, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormComments) FindWithDeleted(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Unscoped().First(&comment, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormComments) SetModerationStatus(ctx context.Context, id uint, status string) error {
+	return r.db.WithContext(ctx).Model(&models.Comment{}).Where("id = ?", id).Update("moderation_status", status).Error
+}
+
+func (r *gormComments) Trash(ctx context.Context, id, deletedBy uint) error {
+	result := r.db.WithContext(ctx).Model(&models.Comment{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "deleted_by_id": deletedBy})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormComments) Restore(ctx context.Context, comment *models.Comment) error {
+	db := r.db.WithContext(ctx)
+	var livePosts int64
+	if err := db.Model(&models.Post{}).Where("id = ?", comment.PostID).Count(&livePosts).Error; err != nil {
+		return err
+	}
+	if livePosts == 0 {
+		return ErrParentPostDeleted
+	}
+	result := db.Unscoped().Model(&models.Comment{}).Where("id = ? AND deleted_at IS NOT NULL", comment.ID).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by_id": nil})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormComments) FindTrashed(ctx context.Context, id, userID uint, since time.Time) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Scopes(Trashed(userID, since)).First(&comment, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormComments) ListTrashed(ctx context.Context, userID uint, since time.Time) ([]models.Comment, error) {
+	db := r.db.WithContext(ctx)
+	livePosts := db.Session(&gorm.Session{NewDB: true}).Model(&models.Post{}).Select("id")
+	var comments []models.Comment
+	err := db.Scopes(Trashed(userID, since)).
+		Where("post_id IN (?)", livePosts).
+		Order("deleted_at desc").Find(&comments).Error
+	return comments, err
+}
+
+func (r *gormComments) FindHeld(ctx context.Context, id, sellerID uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("comments.id = ? AND comments.status = ? AND posts.user_id = ?", id, models.CommentHeld, sellerID).
+		First(&comment).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormComments) ListHeld(ctx context.Context, sellerID uint) ([]models.Comment, error) {
+	db := r.db.WithContext(ctx)
+	ownPosts := db.Session(&gorm.Session{NewDB: true}).Model(&models.Post{}).
+		Select("id").Where("user_id = ?", sellerID)
+	var comments []models.Comment
+	err := db.Preload("User").
+		Where("status = ? AND post_id IN (?)", models.CommentHeld, ownPosts).
+		Order("created_at desc").Find(&comments).Error
+	return comments, err
+}
+
+func (r *gormComments) Publish(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Model(comment).Updates(map[string]interface{}{
+		"status":        models.CommentPublished,
+		"filter_reason": "",
+	}).Error
+}
+
+type gormLikes struct {
+	db *gorm.DB
+}
+
+func (r *gormLikes) Exists(ctx context.Context, postID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Like{}).
+		Where("post_id = ? AND user_id = ?", postID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormLikes) Create(ctx context.Context, like *models.Like) error {
+	return r.db.WithContext(ctx).Create(like).Error
+}
+
+func (r *gormLikes) Delete(ctx context.Context, postID, userID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("post_id = ? AND user_id = ?", postID, userID).Delete(&models.Like{})
+	return result.RowsAffected > 0, result.Error
+}
+
+type gormSubscriptions struct {
+	db *gorm.DB
+}
+
+func (r *gormSubscriptions) Find(ctx context.Context, subscriberID, sellerID uint) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := r.db.WithContext(ctx).Where("subscriber_id = ? AND seller_id = ?", subscriberID, sellerID).
+		First(&subscription).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &subscription, nil
+}
+
+func (r *gormSubscriptions) IsApproved(ctx context.Context, subscriberID, sellerID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Subscription{}).
+		Where("subscriber_id = ? AND seller_id = ? AND status = ?", subscriberID, sellerID, models.SubscriptionApproved).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormSubscriptions) Create(ctx context.Context, subscription *models.Subscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r *gormSubscriptions) Delete(ctx context.Context, subscriberID, sellerID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("subscriber_id = ? AND seller_id = ?", subscriberID, sellerID).
+		Delete(&models.Subscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormSubscriptions) ListPending(ctx context.Context, sellerID uint) ([]models.Subscription, error) {
+	var requests []models.Subscription
+	err := r.db.WithContext(ctx).Preload("Subscriber").
+		Where("seller_id = ? AND status = ?", sellerID, models.SubscriptionPending).
+		Order("created_at").Find(&requests).Error
+	return requests, err
+}
+
+func (r *gormSubscriptions) FindPending(ctx context.Context, id, sellerID uint) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := r.db.WithContext(ctx).Where("id = ? AND seller_id = ? AND status = ?", id, sellerID, models.SubscriptionPending).
+		First(&subscription).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &subscription, nil
+}
+
+func (r *gormSubscriptions) Approve(ctx context.Context, subscription *models.Subscription) error {
+	return r.db.WithContext(ctx).Model(subscription).Updates(map[string]interface{}{
+		"status":      models.SubscriptionApproved,
+		"approved_at": time.Now(),
+	}).Error
+}
+
+func (r *gormSubscriptions) Deny(ctx context.Context, subscription *models.Subscription) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(subscription).Error
+}
+
+func (r *gormSubscriptions) ApproveAll(ctx context.Context, sellerID uint) error {
+	return r.db.WithContext(ctx).Model(&models.Subscription{}).
+		Where("seller_id = ? AND status = ?", sellerID, models.SubscriptionPending).
+		Updates(map[string]interface{}{"status": models.SubscriptionApproved, "approved_at": time.Now()}).Error
+}
+
+func (r *gormSubscriptions) DeleteBetween(ctx context.Context, a, b uint) error {
+	return r.db.WithContext(ctx).Where("(subscriber_id = ? AND seller_id = ?) OR (subscriber_id = ? AND seller_id = ?)",
+		a, b, b, a).Delete(&models.Subscription{}).Error
+}
+
+func (r *gormSubscriptions) ListSubscribers(ctx context.Context, sellerID, viewerID uint) ([]models.User, error) {
+	var subscribers []models.User
+	err := r.db.WithContext(ctx).Select("DISTINCT users.*").
+		Joins("JOIN subscriptions ON users.id = subscriptions.subscriber_id").
+		Where("subscriptions.seller_id = ? AND subscriptions.status = ?", sellerID, models.SubscriptionApproved).
+		Scopes(NotBlocked("users.id", viewerID)).
+		Find(&subscribers).Error
+	return subscribers, err
+}
+
+func (r *gormSubscriptions) ListSellers(ctx context.Context, subscriberID uint) ([]models.User, error) {
+	var sellers []models.User
+	err := r.db.WithContext(ctx).Joins("JOIN subscriptions ON users.id = subscriptions.seller_id").
+		Where("subscriptions.subscriber_id = ? AND subscriptions.status = ?", subscriberID, models.SubscriptionApproved).
+		Find(&sellers).Error
+	return sellers, err
+}
+
+func (r *gormSubscriptions) CountSubscribers(ctx context.Context, sellerID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Subscription{}).
+		Where("seller_id = ? AND status = ?", sellerID, models.SubscriptionApproved).Count(&count).Error
+	return count, err
+}
+
+type gormBlocks struct {
+	db *gorm.DB
+}
+
+func (r *gormBlocks) ListBlocked(ctx context.Context, blockerID uint) ([]models.Block, error) {
+	var blocks []models.Block
+	err := r.db.WithContext(ctx).Preload("Blocked").Where("blocker_id = ?", blockerID).
+		Order("created_at desc").Find(&blocks).Error
+	return blocks, err
+}
+
+func (r *gormBlocks) Block(ctx context.Context, blockerID, blockedID uint) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Block{BlockerID: blockerID, BlockedID: blockedID}).Error
+}
+
+func (r *gormBlocks) Unblock(ctx context.Context, blockerID, blockedID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Delete(&models.Block{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormBlocks) ListMuted(ctx context.Context, muterID uint) ([]models.Mute, error) {
+	var mutes []models.Mute
+	err := r.db.WithContext(ctx).Preload("Muted").Where("muter_id = ?", muterID).
+		Order("created_at desc").Find(&mutes).Error
+	return mutes, err
+}
+
+func (r *gormBlocks) Mute(ctx context.Context, muterID, mutedID uint) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Mute{MuterID: muterID, MutedID: mutedID}).Error
+}
+
+func (r *gormBlocks) Unmute(ctx context.Context, muterID, mutedID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("muter_id = ? AND muted_id = ?", muterID, mutedID).
+		Delete(&models.Mute{})
+	return result.RowsAffected > 0, result.Error
+}
+
+type gormCategories struct {
+	db *gorm.DB
+}
+
+func (r *gormCategories) List(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Order("name").Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategories) FindByID(ctx context.Context, id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &category, nil
+}
+
+func (r *gormCategories) FindBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &category, nil
+}
+
+func (r *gormCategories) Create(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+type gormKeywords struct {
+	db *gorm.DB
+}
+
+func (r *gormKeywords) List(ctx context.Context, sellerID uint) ([]models.BlockedKeyword, error) {
+	var keywords []models.BlockedKeyword
+	err := r.db.WithContext(ctx).Where("seller_id = ?", sellerID).Order("keyword").Find(&keywords).Error
+	return keywords, err
+}
+
+func (r *gormKeywords) Exists(ctx context.Context, sellerID uint, keyword string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.BlockedKeyword{}).
+		Where("seller_id = ? AND keyword = ?", sellerID, keyword).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormKeywords) Create(ctx context.Context, keyword *models.BlockedKeyword) error {
+	return r.db.WithContext(ctx).Create(keyword).Error
+}
+
+func (r *gormKeywords) Delete(ctx context.Context, id, sellerID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("id = ? AND seller_id = ?", id, sellerID).
+		Delete(&models.BlockedKeyword{})
+	return result.RowsAffected > 0, result.Error
+}
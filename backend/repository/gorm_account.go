@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"instagram-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormSessions struct {
+	db *gorm.DB
+}
+
+func (r *gormSessions) Create(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *gormSessions) ListActive(ctx context.Context, userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *gormSessions) Revoke(ctx context.Context, id, userID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormSessions) RevokeAll(ctx context.Context, userID, exceptID uint) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+type gormAPIKeys struct {
+	db *gorm.DB
+}
+
+func (r *gormAPIKeys) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *gormAPIKeys) List(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+func (r *gormAPIKeys) Revoke(ctx context.Context, id, userID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormAPIKeys) RevokeAll(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+type gormRecoveryCodes struct {
+	db *gorm.DB
+}
+
+func (r *gormRecoveryCodes) Replace(ctx context.Context, userID uint, codes []models.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *gormRecoveryCodes) DeleteAll(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+func (r *gormRecoveryCodes) ListUnused(ctx context.Context, userID uint) ([]models.RecoveryCode, error) {
+	var codes []models.RecoveryCode
+	err := r.db.WithContext(ctx).Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error
+	return codes, err
+}
+
+func (r *gormRecoveryCodes) Use(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+type gormIdentities struct {
+	db *gorm.DB
+}
+
+func (r *gormIdentities) Find(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &identity, nil
+}
+
+func (r *gormIdentities) Linked(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ExternalIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormIdentities) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+type gormDataExports struct {
+	db *gorm.DB
+}
+
+func (r *gormDataExports) HasPending(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.DataExport{}).
+		Where("user_id = ? AND status = ?", userID, "pending").Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormDataExports) Create(ctx context.Context, export *models.DataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *gormDataExports) List(ctx context.Context, userID uint) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&exports).Error
+	return exports, err
+}
+
+func (r *gormDataExports) Find(ctx context.Context, id, userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&export).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &export, nil
+}
+
+type gormSettings struct {
+	db *gorm.DB
+}
+
+func (r *gormSettings) Get(ctx context.Context, key string) (string, error) {
+	var setting models.Setting
+	if err := r.db.WithContext(ctx).Where("key = ?", key).First(&setting).Error; err != nil {
+		return "", notFound(err)
+	}
+	return setting.Value, nil
+}
+
+func (r *gormSettings) Set(ctx context.Context, key, value string) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&models.Setting{Key: key, Value: value}).Error
+}
+
+type gormAudit struct {
+	db *gorm.DB
+}
+
+func (r *gormAudit) Record(ctx context.Context, event *models.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *gormAudit) List(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditEvent, error) {
+	query := r.db.WithContext(ctx)
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+		if filter.TargetID != nil {
+			query = query.Where("target_id = ?", *filter.TargetID)
+		}
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	var events []models.AuditEvent
+	err := query.Order("id desc").Offset(offset).Limit(limit).Find(&events).Error
+	return events, err
+}
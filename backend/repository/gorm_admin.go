@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormVerifications struct {
+	db *gorm.DB
+}
+
+func (r *gormVerifications) HasPending(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.VerificationRequest{}).
+		Where("user_id = ? AND status = ?", userID, models.VerificationPending).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormVerifications) Create(ctx context.Context, request *models.VerificationRequest) error {
+	err := r.db.WithContext(ctx).Omit("User").Create(request).Error
+	if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return ErrVerificationPending
+	}
+	return err
+}
+
+func (r *gormVerifications) Latest(ctx context.Context, userID uint) (*models.VerificationRequest, error) {
+	var request models.VerificationRequest
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").First(&request).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &request, nil
+}
+
+func (r *gormVerifications) List(ctx context.Context, status string) ([]models.VerificationRequest, error) {
+	var requests []models.VerificationRequest
+	err := r.db.WithContext(ctx).Preload("User").Where("status = ?", status).Order("created_at").Find(&requests).Error
+	return requests, err
+}
+
+func (r *gormVerifications) FindWithUser(ctx context.Context, id uint) (*models.VerificationRequest, error) {
+	var request models.VerificationRequest
+	if err := r.db.WithContext(ctx).Preload("User").First(&request, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &request, nil
+}
+
+func (r *gormVerifications) Review(ctx context.Context, request *models.VerificationRequest) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.VerificationRequest{}).
+		Where("id = ? AND status = ?", request.ID, models.VerificationPending).
+		Updates(map[string]interface{}{
+			"status":      request.Status,
+			"reason":      request.Reason,
+			"reviewer_id": request.ReviewerID,
+			"reviewed_at": request.ReviewedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+type gormReports struct {
+	db *gorm.DB
+}
+
+func (r *gormReports) FindByID(ctx context.Context, id uint) (*models.Report, error) {
+	var report models.Report
+	if err := r.db.WithContext(ctx).First(&report, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &report, nil
+}
+
+func (r *gormReports) Exists(ctx context.Context, reporterID uint, targetType string, targetID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Report{}).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ?", reporterID, targetType, targetID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormReports) Create(ctx context.Context, report *models.Report) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *gormReports) CountUnresolved(ctx context.Context, targetType string, targetID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status <> ?", targetType, targetID, models.ReportStatusResolved).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *gormReports) List(ctx context.Context, filter ReportFilter, offset, limit int) ([]models.Report, error) {
+	query := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	} else {
+		query = query.Where("status <> ?", models.ReportStatusResolved)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.AssigneeID != 0 {
+		query = query.Where("assignee_id = ?", filter.AssigneeID)
+	}
+	var reports []models.Report
+	err := query.Order("created_at").Offset(offset).Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+func (r *gormReports) Assign(ctx context.Context, report *models.Report, assigneeID uint) error {
+	return r.db.WithContext(ctx).Model(report).Updates(map[string]interface{}{
+		"assignee_id": assigneeID,
+		"status":      models.ReportStatusAssigned,
+	}).Error
+}
+
+func (r *gormReports) ResolveAll(ctx context.Context, targetType string, targetID uint, action string, resolvedByID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status <> ?", targetType, targetID, models.ReportStatusResolved).
+		Updates(map[string]interface{}{
+			"status":         models.ReportStatusResolved,
+			"action":         action,
+			"resolved_by_id": resolvedByID,
+			"resolved_at":    at,
+		}).Error
+}
+
+type gormNotices struct {
+	db *gorm.DB
+}
+
+func (r *gormNotices) Create(ctx context.Context, notice *models.Notice) error {
+	return r.db.WithContext(ctx).Create(notice).Error
+}
+
+func (r *gormNotices) Find(ctx context.Context, id, userID uint) (*models.Notice, error) {
+	var notice models.Notice
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&notice).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &notice, nil
+}
+
+func (r *gormNotices) List(ctx context.Context, userID uint) ([]models.Notice, error) {
+	var notices []models.Notice
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&notices).Error
+	return notices, err
+}
+
+func (r *gormNotices) MarkRead(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&models.Notice{}).Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+func (r *gormNotices) ListAppealed(ctx context.Context) ([]models.Notice, error) {
+	var notices []models.Notice
+	err := r.db.WithContext(ctx).Where("appeal_status = ?", models.AppealPending).Order("appealed_at").Find(&notices).Error
+	return notices, err
+}
+
+func (r *gormNotices) FindAppealed(ctx context.Context, id uint) (*models.Notice, error) {
+	var notice models.Notice
+	if err := r.db.WithContext(ctx).Where("id = ? AND appeal_status = ?", id, models.AppealPending).First(&notice).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &notice, nil
+}
+
+func (r *gormNotices) Update(ctx context.Context, notice *models.Notice, fields ...string) error {
+	return r.db.WithContext(ctx).Model(notice).Select(fields).Updates(notice).Error
+}
@@ -0,0 +1,1258 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Memory is an in-memory stand-in for the database, for tests. Seed it by
+// setting its fields, with IDs, before use; Repositories returns repositories
+// that read and write it with the same rules as the GORM ones.
+type Memory struct {
+	mu            sync.Mutex
+	Users         []models.User
+	Posts         []models.Post
+	Comments      []models.Comment
+	Likes         []models.Like
+	Subscriptions []models.Subscription
+	Blocks        []models.Block
+	Mutes         []models.Mute
+
+	UsernameRedirects []models.UsernameRedirect
+	Categories        []models.Category
+	BlockedKeywords   []models.BlockedKeyword
+	Sessions          []models.Session
+	APIKeys           []models.APIKey
+	RecoveryCodes     []models.RecoveryCode
+	Identities        []models.ExternalIdentity
+	DataExports       []models.DataExport
+	Settings          []models.Setting
+	Verifications     []models.VerificationRequest
+	Reports           []models.Report
+	Notices           []models.Notice
+	AuditEvents       []models.AuditEvent
+}
+
+// errDuplicate stands in for a unique constraint violation.
+var errDuplicate = errors.New("duplicate key value violates unique constraint")
+
+// Repositories returns repositories backed by m.
+func (m *Memory) Repositories() *Repositories {
+	return &Repositories{
+		Users:         memoryUsers{m},
+		Posts:         memoryPosts{m},
+		Comments:      memoryComments{m},
+		Likes:         memoryLikes{m},
+		Subscriptions: memorySubscriptions{m},
+		Blocks:        memoryBlocks{m},
+		Sessions:      memorySessions{m},
+		APIKeys:       memoryAPIKeys{m},
+		RecoveryCodes: memoryRecoveryCodes{m},
+		Identities:    memoryIdentities{m},
+		DataExports:   memoryDataExports{m},
+		Settings:      memorySettings{m},
+		Categories:    memoryCategories{m},
+		Keywords:      memoryKeywords{m},
+		Verifications: memoryVerifications{m},
+		Reports:       memoryReports{m},
+		Notices:       memoryNotices{m},
+		Audit:         memoryAudit{m},
+	}
+}
+
+// The helpers below expect m.mu to be held.
+
+func (m *Memory) user(id uint) (models.User, bool) {
+	for _, u := range m.Users {
+		if u.ID == id && !u.DeletedAt.Valid {
+			return u, true
+		}
+	}
+	return models.User{}, false
+}
+
+func (m *Memory) post(id uint) (models.Post, bool) {
+	for _, p := range m.Posts {
+		if p.ID == id && !p.DeletedAt.Valid {
+			p.User, _ = m.user(p.UserID)
+			return p, true
+		}
+	}
+	return models.Post{}, false
+}
+
+func (m *Memory) blocked(a, b uint) bool {
+	for _, block := range m.Blocks {
+		if !block.DeletedAt.Valid && ((block.BlockerID == a && block.BlockedID == b) ||
+			(block.BlockerID == b && block.BlockedID == a)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Memory) approved(subscriberID, sellerID uint) bool {
+	for _, s := range m.Subscriptions {
+		if !s.DeletedAt.Valid && s.SubscriberID == subscriberID && s.SellerID == sellerID &&
+			s.Status == models.SubscriptionApproved {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Memory) muted(muterID, mutedID uint) bool {
+	for _, mute := range m.Mutes {
+		if !mute.DeletedAt.Valid && mute.MuterID == muterID && mute.MutedID == mutedID {
+			return true
+		}
+	}
+	return false
+}
+
+// moderated is the Moderation scope for one row.
+func moderated(status string, authorID, viewerID uint) bool {
+	switch status {
+	case "":
+		return true
+	case models.ModerationHidden, models.ModerationShadowHidden:
+		return authorID == viewerID
+	default:
+		return false
+	}
+}
+
+// embed fills in what the GORM repositories preload on a post.
+func (m *Memory) embed(post *models.Post, commentAuthors bool) {
+	post.Likes = nil
+	for _, like := range m.Likes {
+		if like.PostID == post.ID && !like.DeletedAt.Valid {
+			post.Likes = append(post.Likes, like)
+		}
+	}
+	post.Comments = nil
+	for _, comment := range m.Comments {
+		if comment.PostID == post.ID && !comment.DeletedAt.Valid &&
+			comment.Status == models.CommentPublished && comment.ModerationStatus == "" {
+			if commentAuthors {
+				comment.User, _ = m.user(comment.UserID)
+			}
+			post.Comments = append(post.Comments, comment)
+		}
+	}
+}
+
+// page returns the bounds of a page of n rows, applying offset and limit the
+// way SQL does; a negative limit means no limit.
+func page(n, offset, limit int) (int, int) {
+	if offset > n {
+		offset = n
+	}
+	end := offset + limit
+	if limit < 0 || end > n {
+		end = n
+	}
+	return offset, end
+}
+
+func softDelete() gorm.DeletedAt {
+	return gorm.DeletedAt{Time: time.Now(), Valid: true}
+}
+
+// trashed is the Trashed scope for one row.
+func trashed(deletedAt gorm.DeletedAt, deletedBy *uint, authorID uint, status string, userID uint, since time.Time) bool {
+	return deletedAt.Valid && deletedAt.Time.After(since) && deletedBy != nil && *deletedBy == userID &&
+		authorID == userID && status != models.ModerationRemoved
+}
+
+// copyFields copies the named fields from src to dst, both pointers to the
+// same struct type, the way Select(fields).Updates(src) does.
+func copyFields(dst, src interface{}, fields []string) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, field := range fields {
+		d.FieldByName(field).Set(s.FieldByName(field))
+	}
+}
+
+type memoryUsers struct{ m *Memory }
+
+func (r memoryUsers) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	user, ok := r.m.user(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r memoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, u := range r.m.Users {
+		if u.Email == email && !u.DeletedAt.Valid {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryUsers) FindByEmailIgnoreCase(ctx context.Context, email string) (*models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, u := range r.m.Users {
+		if strings.EqualFold(u.Email, email) && !u.DeletedAt.Valid {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryUsers) FindByUsername(ctx context.Context, username string) (*models.User, bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, u := range r.m.Users {
+		if u.Username == username && !u.DeletedAt.Valid {
+			return &u, false, nil
+		}
+	}
+	for _, redirect := range r.m.UsernameRedirects {
+		if redirect.OldUsername == username && !redirect.DeletedAt.Valid {
+			if user, ok := r.m.user(redirect.UserID); ok {
+				return &user, true, nil
+			}
+		}
+	}
+	return nil, false, ErrNotFound
+}
+
+func (r memoryUsers) Exists(ctx context.Context, email, username string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, u := range r.m.Users {
+		if (u.Email == email || u.Username == username) && !u.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryUsers) UsernameAvailable(ctx context.Context, username string, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, u := range r.m.Users {
+		if u.Username == username && u.ID != userID && !u.DeletedAt.Valid {
+			return false, nil
+		}
+	}
+	for _, redirect := range r.m.UsernameRedirects {
+		if redirect.OldUsername == username && redirect.UserID != userID && !redirect.DeletedAt.Valid {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r memoryUsers) Create(ctx context.Context, user *models.User) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, u := range r.m.Users {
+		if u.Email == user.Email || u.Username == user.Username {
+			return errDuplicate
+		}
+		if u.ID > user.ID {
+			user.ID = u.ID
+		}
+	}
+	user.ID++
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	r.m.Users = append(r.m.Users, *user)
+	return nil
+}
+
+func (r memoryUsers) Update(ctx context.Context, user *models.User, fields ...string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, u := range r.m.Users {
+		if u.ID == user.ID && !u.DeletedAt.Valid {
+			copyFields(&r.m.Users[i], user, fields)
+			r.m.Users[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (r memoryUsers) Rename(ctx context.Context, userID uint, oldUsername, username string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	kept := r.m.UsernameRedirects[:0]
+	id := uint(0)
+	for _, redirect := range r.m.UsernameRedirects {
+		if redirect.OldUsername != username {
+			kept = append(kept, redirect)
+		}
+		if redirect.ID > id {
+			id = redirect.ID
+		}
+	}
+	redirect := models.UsernameRedirect{OldUsername: oldUsername, UserID: userID}
+	redirect.ID = id + 1
+	redirect.CreatedAt = time.Now()
+	r.m.UsernameRedirects = append(kept, redirect)
+	return nil
+}
+
+func (r memoryUsers) CancelDeletion(ctx context.Context, id uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, u := range r.m.Users {
+		if u.ID == id && !u.DeletedAt.Valid && u.DeletionScheduledAt != nil {
+			r.m.Users[i].DeletionScheduledAt = nil
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryUsers) RevokeVerification(ctx context.Context, id uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, u := range r.m.Users {
+		if u.ID == id && !u.DeletedAt.Valid && u.Verified {
+			r.m.Users[i].Verified = false
+			r.m.Users[i].VerifiedAt = nil
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryUsers) AcceptTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, u := range r.m.Users {
+		if u.ID == id && !u.DeletedAt.Valid && (u.TOTPLastStep == nil || *u.TOTPLastStep < step) {
+			r.m.Users[i].TOTPLastStep = &step
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryUsers) IsBlocked(ctx context.Context, a, b uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return a != b && r.m.blocked(a, b), nil
+}
+
+func (r memoryUsers) ListSellers(ctx context.Context, categoryID uint, offset, limit int) ([]models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var sellers []models.User
+	for _, u := range r.m.Users {
+		if !u.DeletedAt.Valid && u.Role == "seller" && u.CategoryID != nil && *u.CategoryID == categoryID {
+			sellers = append(sellers, u)
+		}
+	}
+	sort.SliceStable(sellers, func(i, j int) bool { return sellers[i].Username < sellers[j].Username })
+	start, end := page(len(sellers), offset, limit)
+	return sellers[start:end], nil
+}
+
+func (r memoryUsers) Search(ctx context.Context, filter UserFilter, offset, limit int) ([]models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	q := strings.ToLower(filter.Query)
+	now := time.Now()
+	var users []models.User
+	for _, u := range r.m.Users {
+		if u.DeletedAt.Valid || (filter.Role != "" && u.Role != filter.Role) ||
+			(filter.Suspended && (u.SuspendedUntil == nil || !u.SuspendedUntil.After(now))) {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(u.Username), q) &&
+			!strings.Contains(strings.ToLower(u.Email), q) && !strings.Contains(strings.ToLower(u.Name), q) {
+			continue
+		}
+		users = append(users, u)
+	}
+	sort.SliceStable(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	start, end := page(len(users), offset, limit)
+	return users[start:end], nil
+}
+
+func (r memoryUsers) Suspend(ctx context.Context, id uint, until *time.Time) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, u := range r.m.Users {
+		if u.ID == id && !u.DeletedAt.Valid {
+			r.m.Users[i].SuspendedUntil = until
+		}
+	}
+	return nil
+}
+
+func (r memoryUsers) AddWarnings(ctx context.Context, id uint, delta int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, u := range r.m.Users {
+		if u.ID == id && !u.DeletedAt.Valid && u.WarningCount+delta >= 0 {
+			r.m.Users[i].WarningCount += delta
+		}
+	}
+	return nil
+}
+
+func (r memoryUsers) BlockedWith(ctx context.Context, userID uint) ([]uint, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var ids []uint
+	for _, block := range r.m.Blocks {
+		switch {
+		case block.DeletedAt.Valid:
+		case block.BlockerID == userID:
+			ids = append(ids, block.BlockedID)
+		case block.BlockedID == userID:
+			ids = append(ids, block.BlockerID)
+		}
+	}
+	return ids, nil
+}
+
+type memoryPosts struct{ m *Memory }
+
+func (r memoryPosts) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	post, ok := r.m.post(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	post.User = models.User{}
+	return &post, nil
+}
+
+func (r memoryPosts) FindWithAuthor(ctx context.Context, id uint) (*models.Post, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	post, ok := r.m.post(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &post, nil
+}
+
+// FindWithMedia returns the images and purchase options the post was created
+// with, which Memory keeps on the post itself.
+func (r memoryPosts) FindWithMedia(ctx context.Context, id uint) (*models.Post, error) {
+	return r.FindWithAuthor(ctx, id)
+}
+
+func (r memoryPosts) FindWithDeleted(ctx context.Context, id uint) (*models.Post, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, p := range r.m.Posts {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryPosts) FindWithDetails(ctx context.Context, id uint) (*models.Post, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	post, ok := r.m.post(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	r.m.embed(&post, true)
+	return &post, nil
+}
+
+func (r memoryPosts) Feed(ctx context.Context, viewerID uint, offset, limit int) ([]models.Post, int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var visible []models.Post
+	for _, p := range r.m.Posts {
+		post, ok := r.m.post(p.ID)
+		if !ok || r.m.blocked(viewerID, post.UserID) ||
+			!moderated(post.ModerationStatus, post.UserID, viewerID) || r.m.muted(viewerID, post.UserID) {
+			continue
+		}
+		if post.UserID != viewerID && post.User.IsPrivate && !r.m.approved(viewerID, post.UserID) {
+			continue
+		}
+		r.m.embed(&post, false)
+		visible = append(visible, post)
+	}
+	sort.SliceStable(visible, func(i, j int) bool { return visible[i].CreatedAt.After(visible[j].CreatedAt) })
+
+	start, end := page(len(visible), offset, limit)
+	return visible[start:end], int64(len(visible)), nil
+}
+
+func (r memoryPosts) Create(ctx context.Context, post *models.Post) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, p := range r.m.Posts {
+		if p.ID > post.ID {
+			post.ID = p.ID
+		}
+	}
+	post.ID++
+	post.CreatedAt = time.Now()
+	post.UpdatedAt = post.CreatedAt
+	for i := range post.PostImages {
+		post.PostImages[i].ID = uint(i + 1)
+		post.PostImages[i].PostID = post.ID
+	}
+	for i := range post.PurchaseOptions {
+		post.PurchaseOptions[i].ID = uint(i + 1)
+		post.PurchaseOptions[i].PostID = post.ID
+	}
+	r.m.Posts = append(r.m.Posts, *post)
+	return nil
+}
+
+func (r memoryPosts) Update(ctx context.Context, post *models.Post, fields ...string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, p := range r.m.Posts {
+		if p.ID == post.ID && !p.DeletedAt.Valid {
+			copyFields(&r.m.Posts[i], post, fields)
+			r.m.Posts[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (r memoryPosts) SetModerationStatus(ctx context.Context, id uint, status string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, p := range r.m.Posts {
+		if p.ID == id && !p.DeletedAt.Valid {
+			r.m.Posts[i].ModerationStatus = status
+		}
+	}
+	return nil
+}
+
+func (r memoryPosts) IDsByAuthor(ctx context.Context, userID uint) ([]uint, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var ids []uint
+	for _, p := range r.m.Posts {
+		if p.UserID == userID && !p.DeletedAt.Valid {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r memoryPosts) ListPublished(ctx context.Context, userID uint, limit int) ([]models.Post, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var posts []models.Post
+	for _, p := range r.m.Posts {
+		if p.UserID == userID && !p.DeletedAt.Valid && p.ModerationStatus == "" {
+			posts = append(posts, p)
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].CreatedAt.After(posts[j].CreatedAt) })
+	start, end := page(len(posts), 0, limit)
+	return posts[start:end], nil
+}
+
+func (r memoryPosts) CountPublished(ctx context.Context, userID uint) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var count int64
+	for _, p := range r.m.Posts {
+		if p.UserID == userID && !p.DeletedAt.Valid && p.ModerationStatus == "" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r memoryPosts) Trash(ctx context.Context, id, deletedBy uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	deletedAt := softDelete()
+	found := false
+	for i, p := range r.m.Posts {
+		if p.ID == id && !p.DeletedAt.Valid {
+			r.m.Posts[i].DeletedAt = deletedAt
+			r.m.Posts[i].DeletedByID = &deletedBy
+			found = true
+		}
+	}
+	if !found {
+		return ErrNotFound
+	}
+	for i, like := range r.m.Likes {
+		if like.PostID == id && !like.DeletedAt.Valid {
+			r.m.Likes[i].DeletedAt = deletedAt
+		}
+	}
+	for i, comment := range r.m.Comments {
+		if comment.PostID == id && !comment.DeletedAt.Valid {
+			r.m.Comments[i].DeletedAt = deletedAt
+		}
+	}
+	return nil
+}
+
+func (r memoryPosts) Restore(ctx context.Context, id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, p := range r.m.Posts {
+		if p.ID != id || !p.DeletedAt.Valid {
+			continue
+		}
+		deletedAt := p.DeletedAt.Time
+		for j, like := range r.m.Likes {
+			if like.PostID == id && like.DeletedAt.Valid && like.DeletedAt.Time.Equal(deletedAt) {
+				r.m.Likes[j].DeletedAt = gorm.DeletedAt{}
+			}
+		}
+		for j, comment := range r.m.Comments {
+			if comment.PostID == id && comment.DeletedAt.Valid && comment.DeletedAt.Time.Equal(deletedAt) {
+				r.m.Comments[j].DeletedAt = gorm.DeletedAt{}
+			}
+		}
+		r.m.Posts[i].DeletedAt = gorm.DeletedAt{}
+		r.m.Posts[i].DeletedByID = nil
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (r memoryPosts) FindTrashed(ctx context.Context, id, userID uint, since time.Time) (*models.Post, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, p := range r.m.Posts {
+		if p.ID == id && trashed(p.DeletedAt, p.DeletedByID, p.UserID, p.ModerationStatus, userID, since) {
+			return &p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryPosts) ListTrashed(ctx context.Context, userID uint, since time.Time) ([]models.Post, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var posts []models.Post
+	for _, p := range r.m.Posts {
+		if trashed(p.DeletedAt, p.DeletedByID, p.UserID, p.ModerationStatus, userID, since) {
+			posts = append(posts, p)
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].DeletedAt.Time.After(posts[j].DeletedAt.Time) })
+	return posts, nil
+}
+
+type memoryComments struct{ m *Memory }
+
+func (r memoryComments) ListVisible(ctx context.Context, postID, viewerID uint) ([]models.Comment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	post, _ := r.m.post(postID)
+	var comments []models.Comment
+	for _, comment := range r.m.Comments {
+		if comment.PostID != postID || comment.DeletedAt.Valid ||
+			r.m.blocked(viewerID, comment.UserID) ||
+			!moderated(comment.ModerationStatus, comment.UserID, viewerID) {
+			continue
+		}
+		if comment.Status != models.CommentPublished && comment.UserID != viewerID && post.UserID != viewerID {
+			continue
+		}
+		comment.User, _ = r.m.user(comment.UserID)
+		comments = append(comments, comment)
+	}
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].CreatedAt.After(comments[j].CreatedAt) })
+	return comments, nil
+}
+
+func (r memoryComments) Create(ctx context.Context, comment *models.Comment) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, c := range r.m.Comments {
+		if c.ID > comment.ID {
+			comment.ID = c.ID
+		}
+	}
+	comment.ID++
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+	if comment.Status == "" {
+		comment.Status = models.CommentPublished
+	}
+	r.m.Comments = append(r.m.Comments, *comment)
+	comment.User, _ = r.m.user(comment.UserID)
+	return nil
+}
+
+func (r memoryComments) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, comment := range r.m.Comments {
+		if comment.ID == id && !comment.DeletedAt.Valid {
+			return &comment, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryComments) FindWithAuthor(ctx context.Context, id uint) (*models.Comment, error) {
+	comment, err := r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	comment.User, _ = r.m.user(comment.UserID)
+	return comment, nil
+}
+
+func (r memoryComments) FindWithDeleted(ctx context.Context, id uint) (*models.Comment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, comment := range r.m.Comments {
+		if comment.ID == id {
+			return &comment, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryComments) SetModerationStatus(ctx context.Context, id uint, status string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, comment := range r.m.Comments {
+		if comment.ID == id && !comment.DeletedAt.Valid {
+			r.m.Comments[i].ModerationStatus = status
+		}
+	}
+	return nil
+}
+
+func (r memoryComments) Trash(ctx context.Context, id, deletedBy uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, comment := range r.m.Comments {
+		if comment.ID == id && !comment.DeletedAt.Valid {
+			r.m.Comments[i].DeletedAt = softDelete()
+			r.m.Comments[i].DeletedByID = &deletedBy
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r memoryComments) Restore(ctx context.Context, comment *models.Comment) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.post(comment.PostID); !ok {
+		return ErrParentPostDeleted
+	}
+	for i, c := range r.m.Comments {
+		if c.ID == comment.ID && c.DeletedAt.Valid {
+			r.m.Comments[i].DeletedAt = gorm.DeletedAt{}
+			r.m.Comments[i].DeletedByID = nil
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r memoryComments) FindTrashed(ctx context.Context, id, userID uint, since time.Time) (*models.Comment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, c := range r.m.Comments {
+		if c.ID == id && trashed(c.DeletedAt, c.DeletedByID, c.UserID, c.ModerationStatus, userID, since) {
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryComments) ListTrashed(ctx context.Context, userID uint, since time.Time) ([]models.Comment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var comments []models.Comment
+	for _, c := range r.m.Comments {
+		if _, live := r.m.post(c.PostID); live &&
+			trashed(c.DeletedAt, c.DeletedByID, c.UserID, c.ModerationStatus, userID, since) {
+			comments = append(comments, c)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].DeletedAt.Time.After(comments[j].DeletedAt.Time)
+	})
+	return comments, nil
+}
+
+func (r memoryComments) FindHeld(ctx context.Context, id, sellerID uint) (*models.Comment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, c := range r.m.Comments {
+		if c.ID != id || c.DeletedAt.Valid || c.Status != models.CommentHeld {
+			continue
+		}
+		if post, ok := r.m.post(c.PostID); ok && post.UserID == sellerID {
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryComments) ListHeld(ctx context.Context, sellerID uint) ([]models.Comment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var comments []models.Comment
+	for _, c := range r.m.Comments {
+		if c.DeletedAt.Valid || c.Status != models.CommentHeld {
+			continue
+		}
+		for _, p := range r.m.Posts {
+			if p.ID == c.PostID && p.UserID == sellerID {
+				c.User, _ = r.m.user(c.UserID)
+				comments = append(comments, c)
+				break
+			}
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].CreatedAt.After(comments[j].CreatedAt) })
+	return comments, nil
+}
+
+func (r memoryComments) Publish(ctx context.Context, comment *models.Comment) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, c := range r.m.Comments {
+		if c.ID == comment.ID && !c.DeletedAt.Valid {
+			r.m.Comments[i].Status = models.CommentPublished
+			r.m.Comments[i].FilterReason = ""
+		}
+	}
+	comment.Status = models.CommentPublished
+	comment.FilterReason = ""
+	return nil
+}
+
+type memoryLikes struct{ m *Memory }
+
+func (r memoryLikes) Exists(ctx context.Context, postID, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, like := range r.m.Likes {
+		if like.PostID == postID && like.UserID == userID && !like.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryLikes) Create(ctx context.Context, like *models.Like) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, l := range r.m.Likes {
+		if l.ID > like.ID {
+			like.ID = l.ID
+		}
+	}
+	like.ID++
+	like.CreatedAt = time.Now()
+	r.m.Likes = append(r.m.Likes, *like)
+	return nil
+}
+
+func (r memoryLikes) Delete(ctx context.Context, postID, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	deleted := false
+	for i, like := range r.m.Likes {
+		if like.PostID == postID && like.UserID == userID && !like.DeletedAt.Valid {
+			r.m.Likes[i].DeletedAt = softDelete()
+			deleted = true
+		}
+	}
+	return deleted, nil
+}
+
+type memorySubscriptions struct{ m *Memory }
+
+func (r memorySubscriptions) Find(ctx context.Context, subscriberID, sellerID uint) (*models.Subscription, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, s := range r.m.Subscriptions {
+		if s.SubscriberID == subscriberID && s.SellerID == sellerID && !s.DeletedAt.Valid {
+			return &s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memorySubscriptions) IsApproved(ctx context.Context, subscriberID, sellerID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.approved(subscriberID, sellerID), nil
+}
+
+func (r memorySubscriptions) Create(ctx context.Context, subscription *models.Subscription) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, s := range r.m.Subscriptions {
+		if s.ID > subscription.ID {
+			subscription.ID = s.ID
+		}
+	}
+	subscription.ID++
+	subscription.CreatedAt = time.Now()
+	if subscription.Status == "" {
+		subscription.Status = models.SubscriptionApproved
+	}
+	r.m.Subscriptions = append(r.m.Subscriptions, *subscription)
+	return nil
+}
+
+func (r memorySubscriptions) Delete(ctx context.Context, subscriberID, sellerID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	deleted := false
+	for i, s := range r.m.Subscriptions {
+		if s.SubscriberID == subscriberID && s.SellerID == sellerID && !s.DeletedAt.Valid {
+			r.m.Subscriptions[i].DeletedAt = softDelete()
+			deleted = true
+		}
+	}
+	return deleted, nil
+}
+
+func (r memorySubscriptions) ListPending(ctx context.Context, sellerID uint) ([]models.Subscription, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var requests []models.Subscription
+	for _, s := range r.m.Subscriptions {
+		if s.SellerID == sellerID && s.Status == models.SubscriptionPending && !s.DeletedAt.Valid {
+			s.Subscriber, _ = r.m.user(s.SubscriberID)
+			requests = append(requests, s)
+		}
+	}
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].CreatedAt.Before(requests[j].CreatedAt) })
+	return requests, nil
+}
+
+func (r memorySubscriptions) FindPending(ctx context.Context, id, sellerID uint) (*models.Subscription, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, s := range r.m.Subscriptions {
+		if s.ID == id && s.SellerID == sellerID && s.Status == models.SubscriptionPending && !s.DeletedAt.Valid {
+			return &s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memorySubscriptions) Approve(ctx context.Context, subscription *models.Subscription) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	for i, s := range r.m.Subscriptions {
+		if s.ID == subscription.ID {
+			r.m.Subscriptions[i].Status = models.SubscriptionApproved
+			r.m.Subscriptions[i].ApprovedAt = &now
+		}
+	}
+	subscription.Status = models.SubscriptionApproved
+	subscription.ApprovedAt = &now
+	return nil
+}
+
+func (r memorySubscriptions) Deny(ctx context.Context, subscription *models.Subscription) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	kept := r.m.Subscriptions[:0]
+	for _, s := range r.m.Subscriptions {
+		if s.ID != subscription.ID {
+			kept = append(kept, s)
+		}
+	}
+	r.m.Subscriptions = kept
+	return nil
+}
+
+func (r memorySubscriptions) ApproveAll(ctx context.Context, sellerID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	for i, s := range r.m.Subscriptions {
+		if s.SellerID == sellerID && s.Status == models.SubscriptionPending && !s.DeletedAt.Valid {
+			r.m.Subscriptions[i].Status = models.SubscriptionApproved
+			r.m.Subscriptions[i].ApprovedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r memorySubscriptions) DeleteBetween(ctx context.Context, a, b uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, s := range r.m.Subscriptions {
+		if !s.DeletedAt.Valid && ((s.SubscriberID == a && s.SellerID == b) || (s.SubscriberID == b && s.SellerID == a)) {
+			r.m.Subscriptions[i].DeletedAt = softDelete()
+		}
+	}
+	return nil
+}
+
+func (r memorySubscriptions) ListSubscribers(ctx context.Context, sellerID, viewerID uint) ([]models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var subscribers []models.User
+	for _, u := range r.m.Users {
+		if !u.DeletedAt.Valid && r.m.approved(u.ID, sellerID) && !r.m.blocked(viewerID, u.ID) {
+			subscribers = append(subscribers, u)
+		}
+	}
+	return subscribers, nil
+}
+
+func (r memorySubscriptions) ListSellers(ctx context.Context, subscriberID uint) ([]models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var sellers []models.User
+	for _, u := range r.m.Users {
+		if !u.DeletedAt.Valid && r.m.approved(subscriberID, u.ID) {
+			sellers = append(sellers, u)
+		}
+	}
+	return sellers, nil
+}
+
+func (r memorySubscriptions) CountSubscribers(ctx context.Context, sellerID uint) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var count int64
+	for _, s := range r.m.Subscriptions {
+		if s.SellerID == sellerID && s.Status == models.SubscriptionApproved && !s.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type memoryBlocks struct{ m *Memory }
+
+func (r memoryBlocks) ListBlocked(ctx context.Context, blockerID uint) ([]models.Block, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var blocks []models.Block
+	for _, block := range r.m.Blocks {
+		if block.BlockerID == blockerID && !block.DeletedAt.Valid {
+			block.Blocked, _ = r.m.user(block.BlockedID)
+			blocks = append(blocks, block)
+		}
+	}
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].CreatedAt.After(blocks[j].CreatedAt) })
+	return blocks, nil
+}
+
+func (r memoryBlocks) Block(ctx context.Context, blockerID, blockedID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	block := models.Block{BlockerID: blockerID, BlockedID: blockedID}
+	for _, b := range r.m.Blocks {
+		if b.BlockerID == blockerID && b.BlockedID == blockedID {
+			return nil
+		}
+		if b.ID > block.ID {
+			block.ID = b.ID
+		}
+	}
+	block.ID++
+	block.CreatedAt = time.Now()
+	r.m.Blocks = append(r.m.Blocks, block)
+	return nil
+}
+
+func (r memoryBlocks) Unblock(ctx context.Context, blockerID, blockedID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, b := range r.m.Blocks {
+		if b.BlockerID == blockerID && b.BlockedID == blockedID {
+			r.m.Blocks = append(r.m.Blocks[:i], r.m.Blocks[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryBlocks) ListMuted(ctx context.Context, muterID uint) ([]models.Mute, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var mutes []models.Mute
+	for _, mute := range r.m.Mutes {
+		if mute.MuterID == muterID && !mute.DeletedAt.Valid {
+			mute.Muted, _ = r.m.user(mute.MutedID)
+			mutes = append(mutes, mute)
+		}
+	}
+	sort.SliceStable(mutes, func(i, j int) bool { return mutes[i].CreatedAt.After(mutes[j].CreatedAt) })
+	return mutes, nil
+}
+
+func (r memoryBlocks) Mute(ctx context.Context, muterID, mutedID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	mute := models.Mute{MuterID: muterID, MutedID: mutedID}
+	for _, m := range r.m.Mutes {
+		if m.MuterID == muterID && m.MutedID == mutedID {
+			return nil
+		}
+		if m.ID > mute.ID {
+			mute.ID = m.ID
+		}
+	}
+	mute.ID++
+	mute.CreatedAt = time.Now()
+	r.m.Mutes = append(r.m.Mutes, mute)
+	return nil
+}
+
+func (r memoryBlocks) Unmute(ctx context.Context, muterID, mutedID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, m := range r.m.Mutes {
+		if m.MuterID == muterID && m.MutedID == mutedID {
+			r.m.Mutes = append(r.m.Mutes[:i], r.m.Mutes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryCategories struct{ m *Memory }
+
+func (r memoryCategories) List(ctx context.Context) ([]models.Category, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var categories []models.Category
+	for _, category := range r.m.Categories {
+		if !category.DeletedAt.Valid {
+			categories = append(categories, category)
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+func (r memoryCategories) FindByID(ctx context.Context, id uint) (*models.Category, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, category := range r.m.Categories {
+		if category.ID == id && !category.DeletedAt.Valid {
+			return &category, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryCategories) FindBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, category := range r.m.Categories {
+		if category.Slug == slug && !category.DeletedAt.Valid {
+			return &category, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryCategories) Create(ctx context.Context, category *models.Category) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, c := range r.m.Categories {
+		if c.Slug == category.Slug {
+			return errDuplicate
+		}
+		if c.ID > category.ID {
+			category.ID = c.ID
+		}
+	}
+	category.ID++
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = category.CreatedAt
+	r.m.Categories = append(r.m.Categories, *category)
+	return nil
+}
+
+type memoryKeywords struct{ m *Memory }
+
+func (r memoryKeywords) List(ctx context.Context, sellerID uint) ([]models.BlockedKeyword, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var keywords []models.BlockedKeyword
+	for _, k := range r.m.BlockedKeywords {
+		if k.SellerID == sellerID && !k.DeletedAt.Valid {
+			keywords = append(keywords, k)
+		}
+	}
+	sort.SliceStable(keywords, func(i, j int) bool { return keywords[i].Keyword < keywords[j].Keyword })
+	return keywords, nil
+}
+
+func (r memoryKeywords) Exists(ctx context.Context, sellerID uint, keyword string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, k := range r.m.BlockedKeywords {
+		if k.SellerID == sellerID && k.Keyword == keyword && !k.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryKeywords) Create(ctx context.Context, keyword *models.BlockedKeyword) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, k := range r.m.BlockedKeywords {
+		if k.SellerID == keyword.SellerID && k.Keyword == keyword.Keyword {
+			return errDuplicate
+		}
+		if k.ID > keyword.ID {
+			keyword.ID = k.ID
+		}
+	}
+	keyword.ID++
+	keyword.CreatedAt = time.Now()
+	r.m.BlockedKeywords = append(r.m.BlockedKeywords, *keyword)
+	return nil
+}
+
+func (r memoryKeywords) Delete(ctx context.Context, id, sellerID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, k := range r.m.BlockedKeywords {
+		if k.ID == id && k.SellerID == sellerID {
+			r.m.BlockedKeywords = append(r.m.BlockedKeywords[:i], r.m.BlockedKeywords[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"instagram-backend/models"
+	"sort"
+	"time"
+)
+
+type memorySessions struct{ m *Memory }
+
+func (r memorySessions) Create(ctx context.Context, session *models.Session) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, s := range r.m.Sessions {
+		if s.ID > session.ID {
+			session.ID = s.ID
+		}
+	}
+	session.ID++
+	session.CreatedAt = time.Now()
+	r.m.Sessions = append(r.m.Sessions, *session)
+	return nil
+}
+
+func (r memorySessions) ListActive(ctx context.Context, userID uint) ([]models.Session, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	var sessions []models.Session
+	for _, s := range r.m.Sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.ExpiresAt.After(now) && !s.DeletedAt.Valid {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r memorySessions) Revoke(ctx context.Context, id, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, s := range r.m.Sessions {
+		if s.ID == id && s.UserID == userID && s.RevokedAt == nil && !s.DeletedAt.Valid {
+			now := time.Now()
+			r.m.Sessions[i].RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memorySessions) RevokeAll(ctx context.Context, userID, exceptID uint) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	var revoked int64
+	for i, s := range r.m.Sessions {
+		if s.UserID == userID && s.ID != exceptID && s.RevokedAt == nil && !s.DeletedAt.Valid {
+			r.m.Sessions[i].RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+type memoryAPIKeys struct{ m *Memory }
+
+func (r memoryAPIKeys) Create(ctx context.Context, key *models.APIKey) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, k := range r.m.APIKeys {
+		if k.KeyHash == key.KeyHash {
+			return errDuplicate
+		}
+		if k.ID > key.ID {
+			key.ID = k.ID
+		}
+	}
+	key.ID++
+	key.CreatedAt = time.Now()
+	r.m.APIKeys = append(r.m.APIKeys, *key)
+	return nil
+}
+
+func (r memoryAPIKeys) List(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var keys []models.APIKey
+	for _, k := range r.m.APIKeys {
+		if k.UserID == userID && !k.DeletedAt.Valid {
+			keys = append(keys, k)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (r memoryAPIKeys) Revoke(ctx context.Context, id, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, k := range r.m.APIKeys {
+		if k.ID == id && k.UserID == userID && k.RevokedAt == nil && !k.DeletedAt.Valid {
+			now := time.Now()
+			r.m.APIKeys[i].RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryAPIKeys) RevokeAll(ctx context.Context, userID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	for i, k := range r.m.APIKeys {
+		if k.UserID == userID && k.RevokedAt == nil && !k.DeletedAt.Valid {
+			r.m.APIKeys[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type memoryRecoveryCodes struct{ m *Memory }
+
+func (r memoryRecoveryCodes) Replace(ctx context.Context, userID uint, codes []models.RecoveryCode) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	id := uint(0)
+	kept := r.m.RecoveryCodes[:0]
+	for _, code := range r.m.RecoveryCodes {
+		if code.UserID != userID {
+			kept = append(kept, code)
+		}
+		if code.ID > id {
+			id = code.ID
+		}
+	}
+	for i := range codes {
+		id++
+		codes[i].ID = id
+		codes[i].CreatedAt = time.Now()
+		kept = append(kept, codes[i])
+	}
+	r.m.RecoveryCodes = kept
+	return nil
+}
+
+func (r memoryRecoveryCodes) DeleteAll(ctx context.Context, userID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	kept := r.m.RecoveryCodes[:0]
+	for _, code := range r.m.RecoveryCodes {
+		if code.UserID != userID {
+			kept = append(kept, code)
+		}
+	}
+	r.m.RecoveryCodes = kept
+	return nil
+}
+
+func (r memoryRecoveryCodes) ListUnused(ctx context.Context, userID uint) ([]models.RecoveryCode, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var codes []models.RecoveryCode
+	for _, code := range r.m.RecoveryCodes {
+		if code.UserID == userID && code.UsedAt == nil && !code.DeletedAt.Valid {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+func (r memoryRecoveryCodes) Use(ctx context.Context, id uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, code := range r.m.RecoveryCodes {
+		if code.ID == id && code.UsedAt == nil && !code.DeletedAt.Valid {
+			now := time.Now()
+			r.m.RecoveryCodes[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryIdentities struct{ m *Memory }
+
+func (r memoryIdentities) Find(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, identity := range r.m.Identities {
+		if identity.Provider == provider && identity.Subject == subject && !identity.DeletedAt.Valid {
+			return &identity, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryIdentities) Linked(ctx context.Context, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, identity := range r.m.Identities {
+		if identity.UserID == userID && !identity.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryIdentities) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, i := range r.m.Identities {
+		if i.Provider == identity.Provider && i.Subject == identity.Subject {
+			return errDuplicate
+		}
+		if i.ID > identity.ID {
+			identity.ID = i.ID
+		}
+	}
+	identity.ID++
+	identity.CreatedAt = time.Now()
+	r.m.Identities = append(r.m.Identities, *identity)
+	return nil
+}
+
+type memoryDataExports struct{ m *Memory }
+
+func (r memoryDataExports) HasPending(ctx context.Context, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, export := range r.m.DataExports {
+		if export.UserID == userID && export.Status == "pending" && !export.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryDataExports) Create(ctx context.Context, export *models.DataExport) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, e := range r.m.DataExports {
+		if e.ID > export.ID {
+			export.ID = e.ID
+		}
+	}
+	export.ID++
+	export.CreatedAt = time.Now()
+	r.m.DataExports = append(r.m.DataExports, *export)
+	return nil
+}
+
+func (r memoryDataExports) List(ctx context.Context, userID uint) ([]models.DataExport, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var exports []models.DataExport
+	for _, export := range r.m.DataExports {
+		if export.UserID == userID && !export.DeletedAt.Valid {
+			exports = append(exports, export)
+		}
+	}
+	sort.SliceStable(exports, func(i, j int) bool { return exports[i].CreatedAt.After(exports[j].CreatedAt) })
+	return exports, nil
+}
+
+func (r memoryDataExports) Find(ctx context.Context, id, userID uint) (*models.DataExport, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, export := range r.m.DataExports {
+		if export.ID == id && export.UserID == userID && !export.DeletedAt.Valid {
+			return &export, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+type memorySettings struct{ m *Memory }
+
+func (r memorySettings) Get(ctx context.Context, key string) (string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, setting := range r.m.Settings {
+		if setting.Key == key {
+			return setting.Value, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (r memorySettings) Set(ctx context.Context, key, value string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, setting := range r.m.Settings {
+		if setting.Key == key {
+			r.m.Settings[i].Value = value
+			r.m.Settings[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	r.m.Settings = append(r.m.Settings, models.Setting{Key: key, Value: value, UpdatedAt: time.Now()})
+	return nil
+}
+
+type memoryAudit struct{ m *Memory }
+
+func (r memoryAudit) Record(ctx context.Context, event *models.AuditEvent) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	event.ID = uint(len(r.m.AuditEvents) + 1)
+	event.CreatedAt = time.Now()
+	r.m.AuditEvents = append(r.m.AuditEvents, *event)
+	return nil
+}
+
+func (r memoryAudit) List(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditEvent, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var events []models.AuditEvent
+	for i := len(r.m.AuditEvents) - 1; i >= 0; i-- {
+		e := r.m.AuditEvents[i]
+		if (filter.ActorID != nil && e.ActorID != *filter.ActorID) ||
+			(filter.Action != "" && e.Action != filter.Action) ||
+			(filter.TargetType != "" && e.TargetType != filter.TargetType) ||
+			(filter.TargetType != "" && filter.TargetID != nil && e.TargetID != *filter.TargetID) ||
+			(filter.RequestID != "" && e.RequestID != filter.RequestID) ||
+			(!filter.Since.IsZero() && e.CreatedAt.Before(filter.Since)) ||
+			(!filter.Until.IsZero() && !e.CreatedAt.Before(filter.Until)) {
+			continue
+		}
+		events = append(events, e)
+	}
+	start, end := page(len(events), offset, limit)
+	return events[start:end], nil
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"instagram-backend/models"
+	"sort"
+	"time"
+)
+
+type memoryVerifications struct{ m *Memory }
+
+func (r memoryVerifications) HasPending(ctx context.Context, userID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, v := range r.m.Verifications {
+		if v.UserID == userID && v.Status == models.VerificationPending && !v.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryVerifications) Create(ctx context.Context, request *models.VerificationRequest) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, v := range r.m.Verifications {
+		if v.UserID == request.UserID && v.Status == models.VerificationPending && !v.DeletedAt.Valid {
+			return ErrVerificationPending
+		}
+		if v.ID > request.ID {
+			request.ID = v.ID
+		}
+	}
+	request.ID++
+	request.CreatedAt = time.Now()
+	request.UpdatedAt = request.CreatedAt
+	stored := *request
+	stored.User = models.User{}
+	r.m.Verifications = append(r.m.Verifications, stored)
+	return nil
+}
+
+func (r memoryVerifications) Latest(ctx context.Context, userID uint) (*models.VerificationRequest, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var latest *models.VerificationRequest
+	for _, v := range r.m.Verifications {
+		if v.UserID == userID && !v.DeletedAt.Valid && (latest == nil || !v.CreatedAt.Before(latest.CreatedAt)) {
+			v := v
+			latest = &v
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r memoryVerifications) List(ctx context.Context, status string) ([]models.VerificationRequest, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var requests []models.VerificationRequest
+	for _, v := range r.m.Verifications {
+		if v.Status == status && !v.DeletedAt.Valid {
+			v.User, _ = r.m.user(v.UserID)
+			requests = append(requests, v)
+		}
+	}
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].CreatedAt.Before(requests[j].CreatedAt) })
+	return requests, nil
+}
+
+func (r memoryVerifications) FindWithUser(ctx context.Context, id uint) (*models.VerificationRequest, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, v := range r.m.Verifications {
+		if v.ID == id && !v.DeletedAt.Valid {
+			v.User, _ = r.m.user(v.UserID)
+			return &v, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryVerifications) Review(ctx context.Context, request *models.VerificationRequest) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, v := range r.m.Verifications {
+		if v.ID == request.ID && v.Status == models.VerificationPending && !v.DeletedAt.Valid {
+			copyFields(&r.m.Verifications[i], request, []string{"Status", "Reason", "ReviewerID", "ReviewedAt"})
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryReports struct{ m *Memory }
+
+func (r memoryReports) FindByID(ctx context.Context, id uint) (*models.Report, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, report := range r.m.Reports {
+		if report.ID == id && !report.DeletedAt.Valid {
+			return &report, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryReports) Exists(ctx context.Context, reporterID uint, targetType string, targetID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, report := range r.m.Reports {
+		if report.ReporterID == reporterID && report.TargetType == targetType && report.TargetID == targetID &&
+			!report.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryReports) Create(ctx context.Context, report *models.Report) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, existing := range r.m.Reports {
+		if existing.ReporterID == report.ReporterID && existing.TargetType == report.TargetType &&
+			existing.TargetID == report.TargetID {
+			return errDuplicate
+		}
+		if existing.ID > report.ID {
+			report.ID = existing.ID
+		}
+	}
+	report.ID++
+	report.CreatedAt = time.Now()
+	report.UpdatedAt = report.CreatedAt
+	r.m.Reports = append(r.m.Reports, *report)
+	return nil
+}
+
+func (r memoryReports) CountUnresolved(ctx context.Context, targetType string, targetID uint) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var count int64
+	for _, report := range r.m.Reports {
+		if report.TargetType == targetType && report.TargetID == targetID &&
+			report.Status != models.ReportStatusResolved && !report.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r memoryReports) List(ctx context.Context, filter ReportFilter, offset, limit int) ([]models.Report, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var reports []models.Report
+	for _, report := range r.m.Reports {
+		if report.DeletedAt.Valid ||
+			(filter.Status != "" && report.Status != filter.Status) ||
+			(filter.Status == "" && report.Status == models.ReportStatusResolved) ||
+			(filter.TargetType != "" && report.TargetType != filter.TargetType) ||
+			(filter.AssigneeID != 0 && (report.AssigneeID == nil || *report.AssigneeID != filter.AssigneeID)) {
+			continue
+		}
+		reports = append(reports, report)
+	}
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].CreatedAt.Before(reports[j].CreatedAt) })
+	start, end := page(len(reports), offset, limit)
+	return reports[start:end], nil
+}
+
+func (r memoryReports) Assign(ctx context.Context, report *models.Report, assigneeID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	report.AssigneeID = &assigneeID
+	report.Status = models.ReportStatusAssigned
+	for i, existing := range r.m.Reports {
+		if existing.ID == report.ID && !existing.DeletedAt.Valid {
+			copyFields(&r.m.Reports[i], report, []string{"AssigneeID", "Status"})
+		}
+	}
+	return nil
+}
+
+func (r memoryReports) ResolveAll(ctx context.Context, targetType string, targetID uint, action string, resolvedByID uint, at time.Time) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, report := range r.m.Reports {
+		if report.TargetType == targetType && report.TargetID == targetID &&
+			report.Status != models.ReportStatusResolved && !report.DeletedAt.Valid {
+			resolvedBy, resolvedAt := resolvedByID, at
+			r.m.Reports[i].Status = models.ReportStatusResolved
+			r.m.Reports[i].Action = action
+			r.m.Reports[i].ResolvedByID = &resolvedBy
+			r.m.Reports[i].ResolvedAt = &resolvedAt
+		}
+	}
+	return nil
+}
+
+type memoryNotices struct{ m *Memory }
+
+func (r memoryNotices) Create(ctx context.Context, notice *models.Notice) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, n := range r.m.Notices {
+		if n.ID > notice.ID {
+			notice.ID = n.ID
+		}
+	}
+	notice.ID++
+	notice.CreatedAt = time.Now()
+	notice.UpdatedAt = notice.CreatedAt
+	r.m.Notices = append(r.m.Notices, *notice)
+	return nil
+}
+
+func (r memoryNotices) Find(ctx context.Context, id, userID uint) (*models.Notice, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, n := range r.m.Notices {
+		if n.ID == id && n.UserID == userID && !n.DeletedAt.Valid {
+			return &n, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryNotices) List(ctx context.Context, userID uint) ([]models.Notice, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var notices []models.Notice
+	for _, n := range r.m.Notices {
+		if n.UserID == userID && !n.DeletedAt.Valid {
+			notices = append(notices, n)
+		}
+	}
+	sort.SliceStable(notices, func(i, j int) bool { return notices[i].CreatedAt.After(notices[j].CreatedAt) })
+	return notices, nil
+}
+
+func (r memoryNotices) MarkRead(ctx context.Context, userID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	for i, n := range r.m.Notices {
+		if n.UserID == userID && n.ReadAt == nil && !n.DeletedAt.Valid {
+			r.m.Notices[i].ReadAt = &now
+		}
+	}
+	return nil
+}
+
+func (r memoryNotices) ListAppealed(ctx context.Context) ([]models.Notice, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var notices []models.Notice
+	for _, n := range r.m.Notices {
+		if n.AppealStatus == models.AppealPending && !n.DeletedAt.Valid {
+			notices = append(notices, n)
+		}
+	}
+	sort.SliceStable(notices, func(i, j int) bool { return notices[i].AppealedAt.Before(*notices[j].AppealedAt) })
+	return notices, nil
+}
+
+func (r memoryNotices) FindAppealed(ctx context.Context, id uint) (*models.Notice, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, n := range r.m.Notices {
+		if n.ID == id && n.AppealStatus == models.AppealPending && !n.DeletedAt.Valid {
+			return &n, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryNotices) Update(ctx context.Context, notice *models.Notice, fields ...string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, n := range r.m.Notices {
+		if n.ID == notice.ID && !n.DeletedAt.Valid {
+			copyFields(&r.m.Notices[i], notice, fields)
+			r.m.Notices[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
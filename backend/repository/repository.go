@@ -0,0 +1,392 @@
+// Package repository puts the queries handlers need behind interfaces, with
+// GORM implementations for the app and an in-memory implementation, Memory,
+// for tests.
+package repository
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a lookup matches nothing.
+	ErrNotFound = errors.New("record not found")
+	// ErrParentPostDeleted is returned when restoring a comment whose post is
+	// still deleted.
+	ErrParentPostDeleted = errors.New("The post this comment was on has been deleted")
+	// ErrVerificationPending is returned when creating a verification request
+	// for a user who already has one awaiting review.
+	ErrVerificationPending = errors.New("A verification request is already pending")
+)
+
+type UserRepository interface {
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// FindByEmailIgnoreCase is for addresses from identity providers, which
+	// may be capitalised differently.
+	FindByEmailIgnoreCase(ctx context.Context, email string) (*models.User, error)
+	// FindByUsername finds the user currently or previously known by
+	// username. redirected is true when username is an old one.
+	FindByUsername(ctx context.Context, username string) (user *models.User, redirected bool, err error)
+	// Exists reports whether a user has the email address or username.
+	Exists(ctx context.Context, email, username string) (bool, error)
+	// UsernameAvailable reports whether username is free for userID, counting
+	// other users' current and previous usernames as taken.
+	UsernameAvailable(ctx context.Context, username string, userID uint) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	// Update saves the named fields of user, such as "Name", zero values
+	// included.
+	Update(ctx context.Context, user *models.User, fields ...string) error
+	// Rename records that userID's username changed from oldUsername to
+	// username: the old one keeps redirecting to the user, and the new one
+	// stops redirecting if it was one of theirs before. It doesn't change the
+	// user itself.
+	Rename(ctx context.Context, userID uint, oldUsername, username string) error
+	// CancelDeletion clears a scheduled account deletion and reports whether
+	// there was one.
+	CancelDeletion(ctx context.Context, id uint) (bool, error)
+	// RevokeVerification removes the verified badge and reports whether the
+	// user had it.
+	RevokeVerification(ctx context.Context, id uint) (bool, error)
+	// AcceptTOTPStep records step as the last TOTP time step the user logged
+	// in with, or reports false if it isn't after the last one.
+	AcceptTOTPStep(ctx context.Context, id uint, step int64) (bool, error)
+	// IsBlocked reports whether either user has blocked the other.
+	IsBlocked(ctx context.Context, a, b uint) (bool, error)
+	// BlockedWith lists the users on either side of a block with userID.
+	BlockedWith(ctx context.Context, userID uint) ([]uint, error)
+	// ListSellers returns a page of the sellers in a category, by username.
+	ListSellers(ctx context.Context, categoryID uint, offset, limit int) ([]models.User, error)
+	// Search returns a page of the users matching filter, by ID.
+	Search(ctx context.Context, filter UserFilter, offset, limit int) ([]models.User, error)
+	// Suspend suspends a user until the given time, or lifts their suspension
+	// if until is nil.
+	Suspend(ctx context.Context, id uint, until *time.Time) error
+	// AddWarnings changes a user's warning count by delta, never taking it
+	// below zero.
+	AddWarnings(ctx context.Context, id uint, delta int) error
+}
+
+// UserFilter narrows UserRepository.Search. Zero fields match every user.
+type UserFilter struct {
+	// Query matches part of the username, email or name, ignoring case.
+	Query string
+	Role  string
+	// Suspended matches only users who are suspended now.
+	Suspended bool
+}
+
+type PostRepository interface {
+	// FindByID loads a post without its associations.
+	FindByID(ctx context.Context, id uint) (*models.Post, error)
+	// FindWithAuthor loads a post and its author.
+	FindWithAuthor(ctx context.Context, id uint) (*models.Post, error)
+	// FindWithMedia loads a post with its author, images and purchase options.
+	FindWithMedia(ctx context.Context, id uint) (*models.Post, error)
+	// FindWithDeleted loads a post whether or not it is deleted.
+	FindWithDeleted(ctx context.Context, id uint) (*models.Post, error)
+	// FindWithDetails loads a post with everything embedded in it: author,
+	// likes, published comments and their authors, images and purchase
+	// options. The result is the same for every viewer, so it can be cached.
+	FindWithDetails(ctx context.Context, id uint) (*models.Post, error)
+	// Feed returns a page of the posts viewerID may see, newest first and
+	// without muted authors, with the total number of such posts.
+	Feed(ctx context.Context, viewerID uint, offset, limit int) ([]models.Post, int64, error)
+	// Create saves a post with its images and purchase options.
+	Create(ctx context.Context, post *models.Post) error
+	// Update saves the named fields of post, such as "Caption".
+	Update(ctx context.Context, post *models.Post, fields ...string) error
+	SetModerationStatus(ctx context.Context, id uint, status string) error
+	// IDsByAuthor lists the IDs of userID's posts.
+	IDsByAuthor(ctx context.Context, userID uint) ([]uint, error)
+	// CountPublished counts userID's posts moderators haven't acted on.
+	CountPublished(ctx context.Context, userID uint) (int64, error)
+	// ListPublished lists userID's newest posts that moderators haven't acted
+	// on, with their images.
+	ListPublished(ctx context.Context, userID uint, limit int) ([]models.Post, error)
+	// Trash soft-deletes a post with its images, purchase options, likes and
+	// comments, remembering who deleted it. Everything gets the same deletion
+	// time, which is how Restore tells what went with the post from what was
+	// deleted before it.
+	Trash(ctx context.Context, id, deletedBy uint) error
+	// Restore undoes Trash.
+	Restore(ctx context.Context, id uint) error
+	// FindTrashed finds a post userID deleted themselves after since. Posts a
+	// moderator removed stay removed, so they aren't found.
+	FindTrashed(ctx context.Context, id, userID uint, since time.Time) (*models.Post, error)
+	// ListTrashed lists the posts FindTrashed would find, most recently
+	// deleted first, with the images and purchase options deleted with them.
+	ListTrashed(ctx context.Context, userID uint, since time.Time) ([]models.Post, error)
+}
+
+type CommentRepository interface {
+	// ListVisible lists the comments on a post that viewerID may see, newest
+	// first, with their authors.
+	ListVisible(ctx context.Context, postID, viewerID uint) ([]models.Comment, error)
+	// Create saves a comment and loads its author.
+	Create(ctx context.Context, comment *models.Comment) error
+	FindByID(ctx context.Context, id uint) (*models.Comment, error)
+	FindWithAuthor(ctx context.Context, id uint) (*models.Comment, error)
+	// FindWithDeleted loads a comment whether or not it is deleted.
+	FindWithDeleted(ctx context.Context, id uint) (*models.Comment, error)
+	SetModerationStatus(ctx context.Context, id uint, status string) error
+	// Trash soft-deletes a comment, remembering who deleted it.
+	Trash(ctx context.Context, id, deletedBy uint) error
+	// Restore undoes Trash, or returns ErrParentPostDeleted while the
+	// comment's post is deleted.
+	Restore(ctx context.Context, comment *models.Comment) error
+	// FindTrashed finds a comment userID deleted themselves after since, the
+	// same way as PostRepository.FindTrashed.
+	FindTrashed(ctx context.Context, id, userID uint, since time.Time) (*models.Comment, error)
+	// ListTrashed lists the comments FindTrashed would find on posts that
+	// still exist, most recently deleted first.
+	ListTrashed(ctx context.Context, userID uint, since time.Time) ([]models.Comment, error)
+	// FindHeld finds a comment held for review on one of sellerID's posts.
+	FindHeld(ctx context.Context, id, sellerID uint) (*models.Comment, error)
+	// ListHeld lists the comments held for review on sellerID's posts, newest
+	// first, with their authors.
+	ListHeld(ctx context.Context, sellerID uint) ([]models.Comment, error)
+	// Publish publishes a held comment.
+	Publish(ctx context.Context, comment *models.Comment) error
+}
+
+type LikeRepository interface {
+	Exists(ctx context.Context, postID, userID uint) (bool, error)
+	Create(ctx context.Context, like *models.Like) error
+	// Delete removes a like and reports whether there was one.
+	Delete(ctx context.Context, postID, userID uint) (bool, error)
+}
+
+type SubscriptionRepository interface {
+	// Find returns the subscription or request from subscriberID to sellerID.
+	Find(ctx context.Context, subscriberID, sellerID uint) (*models.Subscription, error)
+	IsApproved(ctx context.Context, subscriberID, sellerID uint) (bool, error)
+	Create(ctx context.Context, subscription *models.Subscription) error
+	// Delete removes the subscription from subscriberID to sellerID and
+	// reports whether there was one.
+	Delete(ctx context.Context, subscriberID, sellerID uint) (bool, error)
+	// ListPending lists the requests awaiting sellerID's approval, oldest
+	// first, with their subscribers.
+	ListPending(ctx context.Context, sellerID uint) ([]models.Subscription, error)
+	// FindPending returns one of sellerID's pending requests.
+	FindPending(ctx context.Context, id, sellerID uint) (*models.Subscription, error)
+	Approve(ctx context.Context, subscription *models.Subscription) error
+	// Deny removes a request for good, so it can be made again.
+	Deny(ctx context.Context, subscription *models.Subscription) error
+	// ApproveAll approves every request awaiting sellerID's approval.
+	ApproveAll(ctx context.Context, sellerID uint) error
+	// DeleteBetween removes the subscriptions and requests between two users,
+	// in either direction.
+	DeleteBetween(ctx context.Context, a, b uint) error
+	// ListSubscribers lists sellerID's approved subscribers, leaving out users
+	// on either side of a block with viewerID.
+	ListSubscribers(ctx context.Context, sellerID, viewerID uint) ([]models.User, error)
+	// ListSellers lists the sellers subscriberID is an approved subscriber of.
+	ListSellers(ctx context.Context, subscriberID uint) ([]models.User, error)
+	CountSubscribers(ctx context.Context, sellerID uint) (int64, error)
+}
+
+type BlockRepository interface {
+	// ListBlocked lists the blocks blockerID made, newest first, with the
+	// blocked users.
+	ListBlocked(ctx context.Context, blockerID uint) ([]models.Block, error)
+	// Block blocks blockedID for blockerID. Blocking twice is not an error.
+	Block(ctx context.Context, blockerID, blockedID uint) error
+	// Unblock removes a block and reports whether there was one.
+	Unblock(ctx context.Context, blockerID, blockedID uint) (bool, error)
+	// ListMuted lists the mutes muterID made, newest first, with the muted
+	// users.
+	ListMuted(ctx context.Context, muterID uint) ([]models.Mute, error)
+	// Mute mutes mutedID for muterID. Muting twice is not an error.
+	Mute(ctx context.Context, muterID, mutedID uint) error
+	// Unmute removes a mute and reports whether there was one.
+	Unmute(ctx context.Context, muterID, mutedID uint) (bool, error)
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	// ListActive lists userID's sessions that are neither revoked nor
+	// expired, most recently seen first.
+	ListActive(ctx context.Context, userID uint) ([]models.Session, error)
+	// Revoke revokes one of userID's sessions and reports whether there was
+	// one to revoke.
+	Revoke(ctx context.Context, id, userID uint) (bool, error)
+	// RevokeAll revokes userID's sessions but exceptID, which may be zero,
+	// and returns how many it revoked.
+	RevokeAll(ctx context.Context, userID, exceptID uint) (int64, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	// List lists userID's keys, newest first, revoked ones included.
+	List(ctx context.Context, userID uint) ([]models.APIKey, error)
+	// Revoke revokes one of userID's keys and reports whether there was one
+	// to revoke.
+	Revoke(ctx context.Context, id, userID uint) (bool, error)
+	RevokeAll(ctx context.Context, userID uint) error
+}
+
+type RecoveryCodeRepository interface {
+	// Replace discards userID's recovery codes and stores codes instead.
+	Replace(ctx context.Context, userID uint, codes []models.RecoveryCode) error
+	DeleteAll(ctx context.Context, userID uint) error
+	ListUnused(ctx context.Context, userID uint) ([]models.RecoveryCode, error)
+	// Use marks a code used, or reports false if it already was.
+	Use(ctx context.Context, id uint) (bool, error)
+}
+
+type IdentityRepository interface {
+	// Find returns the identity provider knows a user by as subject.
+	Find(ctx context.Context, provider, subject string) (*models.ExternalIdentity, error)
+	// Linked reports whether userID has an identity at any provider.
+	Linked(ctx context.Context, userID uint) (bool, error)
+	Create(ctx context.Context, identity *models.ExternalIdentity) error
+}
+
+type DataExportRepository interface {
+	// HasPending reports whether one of userID's exports is being built.
+	HasPending(ctx context.Context, userID uint) (bool, error)
+	Create(ctx context.Context, export *models.DataExport) error
+	// List lists userID's exports, newest first.
+	List(ctx context.Context, userID uint) ([]models.DataExport, error)
+	// Find returns one of userID's exports.
+	Find(ctx context.Context, id, userID uint) (*models.DataExport, error)
+}
+
+type SettingRepository interface {
+	// Get returns a setting's value, or ErrNotFound if it was never set.
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string) error
+}
+
+type CategoryRepository interface {
+	// List lists the categories by name.
+	List(ctx context.Context) ([]models.Category, error)
+	FindByID(ctx context.Context, id uint) (*models.Category, error)
+	FindBySlug(ctx context.Context, slug string) (*models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+}
+
+type KeywordRepository interface {
+	// List lists sellerID's blocked keywords alphabetically.
+	List(ctx context.Context, sellerID uint) ([]models.BlockedKeyword, error)
+	Exists(ctx context.Context, sellerID uint, keyword string) (bool, error)
+	Create(ctx context.Context, keyword *models.BlockedKeyword) error
+	// Delete removes one of sellerID's keywords and reports whether there was
+	// one.
+	Delete(ctx context.Context, id, sellerID uint) (bool, error)
+}
+
+type VerificationRepository interface {
+	// HasPending reports whether userID has a request awaiting review.
+	HasPending(ctx context.Context, userID uint) (bool, error)
+	// Create returns ErrVerificationPending if the user already has a
+	// pending request.
+	Create(ctx context.Context, request *models.VerificationRequest) error
+	// Latest returns userID's most recent request.
+	Latest(ctx context.Context, userID uint) (*models.VerificationRequest, error)
+	// List lists the requests with status, oldest first, with their users.
+	List(ctx context.Context, status string) ([]models.VerificationRequest, error)
+	// FindWithUser loads a request and the user who made it.
+	FindWithUser(ctx context.Context, id uint) (*models.VerificationRequest, error)
+	// Review records the decision on a pending request and reports false if
+	// it had already been reviewed.
+	Review(ctx context.Context, request *models.VerificationRequest) (bool, error)
+}
+
+type ReportRepository interface {
+	FindByID(ctx context.Context, id uint) (*models.Report, error)
+	// Exists reports whether reporterID has already reported a target.
+	Exists(ctx context.Context, reporterID uint, targetType string, targetID uint) (bool, error)
+	Create(ctx context.Context, report *models.Report) error
+	// CountUnresolved counts the reports about a target that aren't resolved.
+	CountUnresolved(ctx context.Context, targetType string, targetID uint) (int64, error)
+	// List returns a page of the reports matching filter, oldest first.
+	List(ctx context.Context, filter ReportFilter, offset, limit int) ([]models.Report, error)
+	// Assign assigns a report to assigneeID and marks it assigned.
+	Assign(ctx context.Context, report *models.Report, assigneeID uint) error
+	// ResolveAll resolves every unresolved report about a target, recording
+	// the action resolvedByID took.
+	ResolveAll(ctx context.Context, targetType string, targetID uint, action string, resolvedByID uint, at time.Time) error
+}
+
+// ReportFilter narrows ReportRepository.List.
+type ReportFilter struct {
+	// Status matches reports with the status; empty matches unresolved ones.
+	Status     string
+	TargetType string
+	// AssigneeID, if not zero, matches reports assigned to that moderator.
+	AssigneeID uint
+}
+
+type NoticeRepository interface {
+	Create(ctx context.Context, notice *models.Notice) error
+	// Find returns one of userID's notices.
+	Find(ctx context.Context, id, userID uint) (*models.Notice, error)
+	// List lists userID's notices, newest first.
+	List(ctx context.Context, userID uint) ([]models.Notice, error)
+	// MarkRead marks userID's unread notices read.
+	MarkRead(ctx context.Context, userID uint) error
+	// ListAppealed lists the notices with a pending appeal, oldest appeal
+	// first.
+	ListAppealed(ctx context.Context) ([]models.Notice, error)
+	// FindAppealed returns a notice with a pending appeal.
+	FindAppealed(ctx context.Context, id uint) (*models.Notice, error)
+	// Update saves the named fields of notice, such as "AppealStatus".
+	Update(ctx context.Context, notice *models.Notice, fields ...string) error
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, event *models.AuditEvent) error
+	// List returns a page of the events matching filter, newest first.
+	List(ctx context.Context, filter AuditFilter, offset, limit int) ([]models.AuditEvent, error)
+}
+
+// AuditFilter narrows AuditRepository.List. Zero fields match every event.
+type AuditFilter struct {
+	// ActorID matches events by the actor, zero meaning the system.
+	ActorID    *uint
+	Action     string
+	TargetType string
+	// TargetID is only used with TargetType.
+	TargetID  *uint
+	RequestID string
+	// Since and Until bound the events' times, Until exclusively.
+	Since, Until time.Time
+}
+
+// Repositories groups one implementation of each repository.
+type Repositories struct {
+	Users         UserRepository
+	Posts         PostRepository
+	Comments      CommentRepository
+	Likes         LikeRepository
+	Subscriptions SubscriptionRepository
+	Blocks        BlockRepository
+	Sessions      SessionRepository
+	APIKeys       APIKeyRepository
+	RecoveryCodes RecoveryCodeRepository
+	Identities    IdentityRepository
+	DataExports   DataExportRepository
+	Settings      SettingRepository
+	Categories    CategoryRepository
+	Keywords      KeywordRepository
+	Verifications VerificationRepository
+	Reports       ReportRepository
+	Notices       NoticeRepository
+	Audit         AuditRepository
+
+	// transaction runs fn in a transaction; nil runs it directly.
+	transaction func(ctx context.Context, fn func(tx *Repositories) error) error
+}
+
+// Transaction runs fn with repositories whose changes are kept only if fn
+// returns nil.
+func (r *Repositories) Transaction(ctx context.Context, fn func(tx *Repositories) error) error {
+	if r.transaction == nil {
+		return fn(r)
+	}
+	return r.transaction(ctx, fn)
+}
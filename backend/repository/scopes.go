@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"instagram-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VisiblePosts limits a posts query to posts viewerID may see: their own,
+// those of public accounts, and those of private accounts they are an approved
+// subscriber of, excluding anyone on either side of a block.
+func VisiblePosts(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		public := db.Session(&gorm.Session{NewDB: true}).Model(&models.User{}).
+			Select("id").Where("is_private = ?", false)
+		approved := db.Session(&gorm.Session{NewDB: true}).Model(&models.Subscription{}).
+			Select("seller_id").Where("subscriber_id = ? AND status = ?", viewerID, models.SubscriptionApproved)
+		return db.Where("posts.user_id = ? OR posts.user_id IN (?) OR posts.user_id IN (?)", viewerID, public, approved).
+			Scopes(NotBlocked("posts.user_id", viewerID), Moderation("posts", viewerID))
+	}
+}
+
+// VisibleComments hides comments by blocked users and comments removed or
+// hidden by moderation, except hidden ones from their own author. Comments held
+// by the content filter are only shown to their author and the post's owner.
+func VisibleComments(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ownPosts := db.Session(&gorm.Session{NewDB: true}).Model(&models.Post{}).
+			Select("id").Where("user_id = ?", viewerID)
+		return db.Where("comments.status = ? OR comments.user_id = ? OR comments.post_id IN (?)",
+			models.CommentPublished, viewerID, ownPosts).
+			Scopes(NotBlocked("comments.user_id", viewerID), Moderation("comments", viewerID))
+	}
+}
+
+// Moderation keeps rows of table with no moderation status, plus hidden and
+// shadow-hidden rows authored by viewerID.
+func Moderation(table string, viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(table+".moderation_status = ? OR ("+table+".moderation_status IN (?) AND "+table+".user_id = ?)",
+			"", []string{models.ModerationHidden, models.ModerationShadowHidden}, viewerID)
+	}
+}
+
+// NotBlocked drops rows whose column holds a user that viewerID has blocked or
+// been blocked by.
+func NotBlocked(column string, viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		blocked := db.Session(&gorm.Session{NewDB: true}).Model(&models.Block{}).
+			Select("blocked_id").Where("blocker_id = ?", viewerID)
+		blockedBy := db.Session(&gorm.Session{NewDB: true}).Model(&models.Block{}).
+			Select("blocker_id").Where("blocked_id = ?", viewerID)
+		return db.Where(column+" NOT IN (?) AND "+column+" NOT IN (?)", blocked, blockedBy)
+	}
+}
+
+// NotMuted drops posts by users viewerID has muted. It only applies to the
+// feed; muted users' posts stay reachable directly.
+func NotMuted(viewerID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		muted := db.Session(&gorm.Session{NewDB: true}).Model(&models.Mute{}).
+			Select("muted_id").Where("muter_id = ?", viewerID)
+		return db.Where("posts.user_id NOT IN (?)", muted)
+	}
+}
+
+// PublishedComments is a Preload condition for comments that passed the
+// content filter and that no moderator has acted on. Embedded comments are
+// cached and shared between viewers, so per-viewer rules are applied
+// afterwards.
+func PublishedComments(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ? AND moderation_status = ?", models.CommentPublished, "")
+}
+
+// Trashed selects posts or comments userID deleted themselves after since, so
+// they can still be restored. Content a moderator removed stays removed, so it
+// isn't offered back.
+func Trashed(userID uint, since time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where("deleted_at > ? AND deleted_by_id = ? AND user_id = ? AND moderation_status <> ?",
+			since, userID, userID, models.ModerationRemoved)
+	}
+}
@@ -0,0 +1,45 @@
+package router_test
+
+import (
+	"instagram-backend/app/apptest"
+	"instagram-backend/models"
+	"net/http"
+	"testing"
+)
+
+// apiKey creates a key for the fixture's seller with scopes and returns a
+// client that sends it.
+func (f *fixture) apiKey(scopes ...string) *apptest.Client {
+	var key struct {
+		Key string `json:"key"`
+	}
+	f.As(f.seller).Post(v1("/me/api-keys"), map[string]interface{}{"name": "shop", "scopes": scopes}).
+		Expect(http.StatusCreated).Decode(&key)
+	return f.WithAPIKey(key.Key)
+}
+
+func TestAPIKeyScopesGuardPurchaseOptions(t *testing.T) {
+	f := newFixture(t)
+	product := f.CreatePost(f.seller, func(p *models.Post) {
+		p.PurchaseOptions = []models.PurchaseOption{{Platform: "Amazon", URL: "https://example.com/buy"}}
+	})
+
+	posts := f.apiKey(models.ScopePostsWrite)
+	posts.Post(v1("/posts"), map[string]interface{}{
+		"caption":         "New stock",
+		"contentType":     "feed",
+		"imageUrls":       []string{"https://example.com/new.jpg"},
+		"purchaseOptions": []map[string]string{{"platform": "Amazon", "url": "https://example.com/buy"}},
+	}).Expect(http.StatusForbidden)
+	posts.Delete(v1("/posts/%d", product.ID)).Expect(http.StatusForbidden)
+	posts.Delete(v1("/posts/%d", f.post.ID)).Expect(http.StatusOK)
+
+	f.apiKey(models.ScopePostsWrite, models.ScopeProductsWrite).
+		Delete(v1("/posts/%d", product.ID)).Expect(http.StatusOK)
+}
+
+func TestAPIKeyUnknownScopeRejected(t *testing.T) {
+	f := newFixture(t)
+	f.As(f.seller).Post(v1("/me/api-keys"), map[string]interface{}{"name": "shop", "scopes": []string{"insights:read"}}).
+		Expect(http.StatusBadRequest)
+}
@@ -0,0 +1,31 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCategorySellersHidePrivateFields(t *testing.T) {
+	f := newFixture(t)
+
+	slug := strings.SplitN(f.seller.Username, "/", 2)[0]
+	resp := f.Anonymous().Get(v1("/categories/%s/sellers", slug)).Expect(http.StatusOK)
+
+	var sellers []map[string]json.RawMessage
+	resp.Decode(&sellers)
+	if len(sellers) != 1 {
+		t.Fatalf("sellers = %s, want the fixture's seller", resp.Body)
+	}
+	for _, field := range []string{"id", "username", "name", "profileImage", "verified"} {
+		if _, ok := sellers[0][field]; !ok {
+			t.Errorf("seller has no %q field: %s", field, resp.Body)
+		}
+	}
+	for _, field := range []string{"email", "emailVerifiedAt", "suspendedUntil", "warningCount", "deletionScheduledAt"} {
+		if _, ok := sellers[0][field]; ok {
+			t.Errorf("seller exposes %q: %s", field, resp.Body)
+		}
+	}
+}
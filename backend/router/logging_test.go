@@ -0,0 +1,71 @@
+package router_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"instagram-backend/app/apptest"
+	"instagram-backend/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	s := apptest.New(t)
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name string
+		sent string
+		want func(string) bool
+	}{
+		{"caller's ID", "trace-123", func(id string) bool { return id == "trace-123" }},
+		{"missing ID", "", generated.MatchString},
+		{"unsafe ID", "bad id\tforged=1", generated.MatchString},
+	}
+	for _, tt := range tests {
+		client := s.Anonymous()
+		if tt.sent != "" {
+			client.Header.Set(middleware.RequestIDHeader, tt.sent)
+		}
+		id := client.Get("/api/v1/auth/oidc/providers").Expect(http.StatusOK).Header().Get(middleware.RequestIDHeader)
+		if !tt.want(id) {
+			t.Errorf("%s: %s = %q", tt.name, middleware.RequestIDHeader, id)
+			continue
+		}
+		if !loggedRequest(s, id) {
+			t.Errorf("%s: no access log entry with request_id %q", tt.name, id)
+		}
+	}
+}
+
+func loggedRequest(s *apptest.Server, id string) bool {
+	for _, entry := range s.Logs.Entries("Request") {
+		if entry["request_id"] == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAccessLog(t *testing.T) {
+	s := apptest.New(t)
+	buyer := s.CreateUser()
+	s.As(buyer).Get("/api/v1/me/sessions").Expect(http.StatusOK)
+	s.Anonymous().Get("/api/v1/me/sessions").Expect(http.StatusUnauthorized)
+
+	entries := s.Logs.Entries("Request")
+	byStatus := map[float64]map[string]interface{}{}
+	for _, entry := range entries {
+		if entry["route"] == "/api/v1/me/sessions" {
+			byStatus[entry["status"].(float64)] = entry
+		}
+	}
+
+	ok := byStatus[http.StatusOK]
+	if ok == nil || ok["level"] != "INFO" || ok["user_id"] != float64(buyer.ID) || ok["method"] != http.MethodGet {
+		t.Errorf("200 entry = %v", ok)
+	}
+	if unauthorized := byStatus[http.StatusUnauthorized]; unauthorized == nil || unauthorized["level"] != "WARN" {
+		t.Errorf("401 entry = %v", unauthorized)
+	}
+}
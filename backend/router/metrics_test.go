@@ -0,0 +1,75 @@
+package router_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"instagram-backend/app/apptest"
+	"instagram-backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetrics(t *testing.T) {
+	s := apptest.New(t)
+	seller := s.CreateSeller()
+	buyer := s.CreateUser()
+	post := s.CreatePost(seller)
+
+	s.Anonymous().Post("/api/v1/register", map[string]string{
+		"username": "newbuyer",
+		"email":    "newbuyer@example.com",
+		"password": apptest.Password,
+		"name":     "New Buyer",
+		"role":     "buyer",
+	}).Expect(http.StatusCreated)
+	client := s.As(buyer)
+	client.Post(fmt.Sprintf("/api/v1/posts/%d/like", post.ID), nil).Expect(http.StatusCreated)
+	client.Get(fmt.Sprintf("/api/v1/posts/%d", post.ID)).Expect(http.StatusOK)
+	client.Get(fmt.Sprintf("/api/v1/posts/%d", post.ID)).Expect(http.StatusOK)
+	client.Get("/api/v1/posts").Expect(http.StatusOK)
+
+	body := s.Anonymous().Get("/metrics").Expect(http.StatusOK).Body.String()
+	for _, want := range []string{
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/posts/:id",status="200"} 2`,
+		`http_request_duration_seconds_count{method="POST",route="/api/v1/register",status="201"} 1`,
+		`cache_lookups_total{cache="post",result="hit"} 1`,
+		`cache_lookups_total{cache="post",result="miss"} 1`,
+		`cache_lookups_total{cache="posts_page",result="miss"} 1`,
+		`registrations_total{method="password"} 1`,
+		`likes_total 1`,
+		`posts_created_total 0`,
+		`go_sql_open_connections{db_name="main"}`,
+		`redis_pool_connections`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestMetricsCountPanics(t *testing.T) {
+	s := apptest.New(t)
+	s.Router.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	s.Anonymous().Get("/panic").Expect(http.StatusInternalServerError)
+
+	body := s.Anonymous().Get("/metrics").Expect(http.StatusOK).Body.String()
+	want := `http_request_duration_seconds_count{method="GET",route="/panic",status="500"} 1`
+	if !strings.Contains(body, want) {
+		t.Errorf("metrics lack %s", want)
+	}
+}
+
+func TestMetricsToken(t *testing.T) {
+	s := apptest.New(t, func(cfg *config.Config) { cfg.Metrics.Token = "scrape-me" })
+
+	s.Anonymous().Get("/metrics").Expect(http.StatusUnauthorized)
+	client := s.Anonymous()
+	client.Header.Set("Authorization", "Bearer wrong")
+	client.Get("/metrics").Expect(http.StatusUnauthorized)
+	client.Header.Set("Authorization", "Bearer scrape-me")
+	client.Get("/metrics").Expect(http.StatusOK)
+}
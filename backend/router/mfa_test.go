@@ -0,0 +1,52 @@
+package router_test
+
+import (
+	"net/http"
+	"testing"
+
+	"instagram-backend/app/apptest"
+	"instagram-backend/models"
+)
+
+// challenge logs user in with their password and returns the MFA token.
+func (f *fixture) challenge(user *models.User) string {
+	f.T().Helper()
+	var res struct {
+		MFAToken string `json:"mfaToken"`
+	}
+	f.Anonymous().Post(v1("/login"), map[string]string{"email": user.Email, "password": apptest.Password}).
+		Expect(http.StatusOK).Decode(&res)
+	return res.MFAToken
+}
+
+func TestTwoFactorChallengeIsSingleUse(t *testing.T) {
+	f := newFixture(t)
+	user := f.withTOTP()
+	token := f.challenge(user)
+
+	f.Anonymous().Post(v1("/login/2fa"), map[string]string{"mfaToken": token, "code": f.code(user)}).Expect(http.StatusOK)
+
+	// Forget the code so only the spent challenge stands in the way.
+	if err := f.DB.Model(user).Update("totp_last_step", nil).Error; err != nil {
+		t.Fatal(err)
+	}
+	f.Anonymous().Post(v1("/login/2fa"), map[string]string{"mfaToken": token, "code": f.code(user)}).Expect(http.StatusUnauthorized)
+}
+
+func TestTwoFactorCodeIsSingleUse(t *testing.T) {
+	f := newFixture(t)
+	user := f.withTOTP()
+	code := f.code(user)
+
+	f.Anonymous().Post(v1("/login/2fa"), map[string]string{"mfaToken": f.challenge(user), "code": code}).Expect(http.StatusOK)
+	f.Anonymous().Post(v1("/login/2fa"), map[string]string{"mfaToken": f.challenge(user), "code": code}).Expect(http.StatusUnauthorized)
+}
+
+func TestTwoFactorFailsClosedWithoutRedis(t *testing.T) {
+	f := newFixture(t)
+	user := f.withTOTP()
+	token := f.challenge(user)
+
+	f.Redis.SetError("connection refused")
+	f.Anonymous().Post(v1("/login/2fa"), map[string]string{"mfaToken": token, "code": f.code(user)}).Expect(http.StatusServiceUnavailable)
+}
@@ -0,0 +1,49 @@
+package router_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"instagram-backend/app/apptest"
+)
+
+// injected is an :id that GORM would run as a SQL condition if handlers
+// passed it to First as a string.
+var injected = url.PathEscape("1 OR 1=1")
+
+func TestIDParamsAreNumeric(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		route string
+		do    func() *apptest.Response
+	}{
+		{"GET /p/:id", func() *apptest.Response {
+			return f.Anonymous().Get("/p/" + injected)
+		}},
+		{"POST /api/v1/me/trash/posts/:id/restore", func() *apptest.Response {
+			return f.As(f.buyer).Post(v1("/me/trash/posts/%s/restore", injected), nil)
+		}},
+		{"POST /api/v1/me/trash/comments/:id/restore", func() *apptest.Response {
+			return f.As(f.buyer).Post(v1("/me/trash/comments/%s/restore", injected), nil)
+		}},
+		{"POST /api/v1/admin/reports/:id/assign", func() *apptest.Response {
+			return f.As(f.admin).Post(v1("/admin/reports/%s/assign", injected), nil)
+		}},
+		{"POST /api/v1/admin/reports/:id/resolve", func() *apptest.Response {
+			return f.As(f.admin).Post(v1("/admin/reports/%s/resolve", injected), map[string]string{"action": "dismiss"})
+		}},
+		{"POST /api/v1/admin/verifications/:id/approve", func() *apptest.Response {
+			return f.As(f.admin).Post(v1("/admin/verifications/%s/approve", injected), nil)
+		}},
+		{"POST /api/v1/admin/users/:id/verify-email", func() *apptest.Response {
+			return f.As(f.admin).Post(v1("/admin/users/%s/verify-email", injected), nil)
+		}},
+	}
+	for _, tt := range tests {
+		if resp := tt.do(); resp.Code != http.StatusNotFound {
+			t.Errorf("%s with id %q: status = %d, want %d", tt.route, injected, resp.Code, http.StatusNotFound)
+		}
+	}
+}
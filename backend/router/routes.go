@@ -1,9 +1,10 @@
 package router
 
 import (
+	"instagram-backend/app"
 	"instagram-backend/config"
-	"instagram-backend/handlers"
 	"instagram-backend/middleware"
+	"instagram-backend/models"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -11,15 +12,30 @@ import (
 )
 
 // @BasePath /api/v1
-func SetupRouter() *gin.Engine {
-	r := config.SetupServer()
+func SetupRouter(app *app.App) *gin.Engine {
+	r := config.SetupServer(app.Config, app.Logger, app.Metrics, app.Tracer)
+
+	// Prometheus metrics
+	r.GET("/metrics", middleware.MetricsToken(app.Config.Metrics.Token), gin.WrapH(app.Metrics.Handler()))
 
 	// Swagger documentation endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(config.Db)
-	postHandler := handlers.NewPostHandler(config.Db)
+	authHandler := app.Handlers.Auth
+	postHandler := app.Handlers.Post
+	oidcHandler := app.Handlers.OIDC
+	categoryHandler := app.Handlers.Category
+	pageHandler := app.Handlers.Page
+	moderationHandler := app.Handlers.Moderation
+	adminHandler := app.Handlers.Admin
+	verificationHandler := app.Handlers.Verification
+
+	// Public keys for verifying our JWTs
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Shareable HTML pages with link previews
+	r.GET("/u/*username", pageHandler.ProfilePage)
+	r.GET("/p/:id", pageHandler.PostPage)
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
@@ -27,22 +43,92 @@ func SetupRouter() *gin.Engine {
 		// Public routes
 		v1.POST("/register", authHandler.Register)
 		v1.POST("/login", authHandler.Login)
+		v1.POST("/login/2fa", authHandler.LoginTwoFactor)
+		v1.GET("/verify-email", authHandler.VerifyEmail)
+
+		// Social login
+		v1.GET("/auth/oidc/providers", oidcHandler.ListProviders)
+		v1.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+		v1.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+		v1.POST("/auth/oidc/:provider/callback", oidcHandler.Callback)
+
+		// Storefront categories
+		v1.GET("/categories", categoryHandler.ListCategories)
+		v1.GET("/categories/:slug/sellers", categoryHandler.GetCategorySellers)
+
+		// Public profiles
+		v1.GET("/profiles/*username", authHandler.GetPublicProfile)
+
+		// Routes suspended users can still reach to learn why and appeal
+		account := v1.Group("/")
+		account.Use(middleware.AuthMiddleware(app.DB, app.Keys), middleware.RejectAPIKeys())
+		{
+			account.GET("/me/notices", moderationHandler.ListNotices)
+			account.POST("/me/notices/:id/appeal", moderationHandler.AppealNotice)
+		}
 
 		// Protected routes
 		protected := v1.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(app.DB, app.Keys), middleware.RejectAPIKeys(), middleware.RejectSuspended(app.DB))
 		{
+			// Email verification
+			protected.POST("/verify-email/resend", authHandler.ResendVerificationEmail)
+
+			// Two-factor authentication
+			protected.POST("/me/2fa/totp/enroll", middleware.RejectImpersonation(), authHandler.EnrollTOTP)
+			protected.POST("/me/2fa/totp/verify", middleware.RejectImpersonation(), authHandler.ConfirmTOTP)
+			protected.DELETE("/me/2fa/totp", middleware.RejectImpersonation(), authHandler.DisableTOTP)
+
+			// API key management
+			protected.GET("/me/api-keys", authHandler.ListAPIKeys)
+			protected.POST("/me/api-keys", middleware.RejectImpersonation(), authHandler.CreateAPIKey)
+			protected.DELETE("/me/api-keys/:id", authHandler.RevokeAPIKey)
+
+			// Session routes
+			protected.GET("/me/sessions", authHandler.ListSessions)
+			protected.DELETE("/me/sessions", authHandler.RevokeOtherSessions)
+			protected.DELETE("/me/sessions/:id", authHandler.RevokeSession)
+
+			// Account deletion and data export
+			protected.DELETE("/me", middleware.RejectImpersonation(), authHandler.DeleteAccount)
+			protected.POST("/me/deletion/cancel", authHandler.CancelAccountDeletion)
+			protected.GET("/me/exports", authHandler.ListDataExports)
+			protected.POST("/me/exports", middleware.RejectImpersonation(), authHandler.RequestDataExport)
+			protected.GET("/me/exports/:id/download", middleware.RejectImpersonation(), authHandler.DownloadDataExport)
+
 			// User routes
+			protected.PUT("/me/username", authHandler.ChangeUsername)
+			protected.GET("/users/by-username/*username", authHandler.GetUserByUsername)
 			protected.GET("/users/:id", authHandler.GetUser)
 			protected.PUT("/users/:id", authHandler.UpdateUser)
 			protected.GET("/users/:id/subscribers", authHandler.GetUserSubscribers)
 
+			// Subscriptions
+			protected.POST("/users/:id/subscribe", authHandler.Subscribe)
+			protected.DELETE("/users/:id/subscribe", authHandler.Unsubscribe)
+			protected.PUT("/me/privacy", authHandler.UpdatePrivacy)
+			protected.GET("/me/subscription-requests", authHandler.ListSubscriptionRequests)
+			protected.POST("/me/subscription-requests/:id/approve", authHandler.ApproveSubscriptionRequest)
+			protected.POST("/me/subscription-requests/:id/deny", authHandler.DenySubscriptionRequest)
+
+			// Blocking and muting
+			protected.GET("/me/blocked", authHandler.ListBlocked)
+			protected.POST("/me/blocked", authHandler.BlockUser)
+			protected.DELETE("/me/blocked/:id", authHandler.UnblockUser)
+			protected.GET("/me/muted", authHandler.ListMuted)
+			protected.POST("/me/muted", authHandler.MuteUser)
+			protected.DELETE("/me/muted/:id", authHandler.UnmuteUser)
+
+			// Reporting
+			protected.POST("/reports", moderationHandler.CreateReport)
+
+			// Seller verification
+			protected.GET("/me/verification", verificationHandler.GetVerification)
+			protected.POST("/me/verification", verificationHandler.SubmitVerification)
+
 			// Post routes
-			protected.POST("/posts", postHandler.CreatePost)
 			protected.GET("/posts", postHandler.GetPosts)
 			protected.GET("/posts/:id", postHandler.GetPost)
-			protected.PUT("/posts/:id", postHandler.UpdatePost)
-			protected.DELETE("/posts/:id", postHandler.DeletePost)
 
 			// Like routes
 			protected.POST("/posts/:id/like", postHandler.LikePost)
@@ -52,6 +138,65 @@ func SetupRouter() *gin.Engine {
 			protected.POST("/posts/:id/comments", postHandler.CreateComment)
 			protected.GET("/posts/:id/comments", postHandler.GetComments)
 			protected.DELETE("/posts/:id/comments/:commentId", postHandler.DeleteComment)
+
+			// Comments held by the content filter, reviewed by the post's owner
+			protected.GET("/me/held-comments", postHandler.ListHeldComments)
+			protected.POST("/me/held-comments/:id/approve", postHandler.ApproveHeldComment)
+			protected.DELETE("/me/held-comments/:id", postHandler.RejectHeldComment)
+			protected.GET("/me/blocked-keywords", postHandler.ListBlockedKeywords)
+			protected.POST("/me/blocked-keywords", postHandler.AddBlockedKeyword)
+			protected.DELETE("/me/blocked-keywords/:id", postHandler.RemoveBlockedKeyword)
+
+			// Recently deleted posts and comments
+			protected.GET("/me/trash", postHandler.ListTrash)
+			protected.POST("/me/trash/posts/:id/restore", postHandler.RestorePost)
+			protected.POST("/me/trash/comments/:id/restore", postHandler.RestoreComment)
+		}
+
+		// Routes seller integrations may call with a scoped X-API-Key as
+		// well as with a user token
+		integrations := v1.Group("/")
+		integrations.Use(middleware.AuthMiddleware(app.DB, app.Keys), middleware.RejectSuspended(app.DB))
+		{
+			integrations.POST("/posts", middleware.RequireScope(models.ScopePostsWrite), postHandler.CreatePost)
+			integrations.PUT("/posts/:id", middleware.RequireScope(models.ScopePostsWrite), postHandler.UpdatePost)
+			integrations.DELETE("/posts/:id", middleware.RequireScope(models.ScopePostsWrite), postHandler.DeletePost)
+		}
+
+		// Admin routes
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(app.DB, app.Keys), middleware.RejectAPIKeys(), middleware.RequireRole(app.DB, "admin"))
+		{
+			admin.GET("/settings/seller-2fa", authHandler.GetSellerTwoFactorRequirement)
+			admin.PUT("/settings/seller-2fa", authHandler.SetSellerTwoFactorRequirement)
+			admin.POST("/categories", categoryHandler.CreateCategory)
+
+			// Moderation queue
+			admin.GET("/reports", moderationHandler.ListReports)
+			admin.POST("/reports/:id/assign", moderationHandler.AssignReport)
+			admin.POST("/reports/:id/resolve", moderationHandler.ResolveReport)
+			admin.GET("/appeals", moderationHandler.ListAppeals)
+			admin.POST("/appeals/:id/resolve", moderationHandler.ResolveAppeal)
+
+			// User and content management
+			admin.GET("/users", adminHandler.SearchUsers)
+			admin.POST("/users/:id/suspend", adminHandler.SuspendUser)
+			admin.DELETE("/users/:id/suspend", adminHandler.UnsuspendUser)
+			admin.POST("/users/:id/verify-email", adminHandler.VerifyUserEmail)
+			admin.PUT("/users/:id/role", adminHandler.ChangeRole)
+			admin.POST("/users/:id/impersonate", adminHandler.ImpersonateUser)
+			admin.DELETE("/posts/:id", adminHandler.DeletePost)
+			admin.POST("/posts/:id/restore", adminHandler.RestorePost)
+			admin.DELETE("/comments/:id", adminHandler.DeleteComment)
+			admin.POST("/comments/:id/restore", adminHandler.RestoreComment)
+
+			// Seller verification review
+			admin.GET("/verifications", verificationHandler.ListVerifications)
+			admin.POST("/verifications/:id/approve", verificationHandler.ApproveVerification)
+			admin.POST("/verifications/:id/reject", verificationHandler.RejectVerification)
+
+			// Audit log
+			admin.GET("/audit", adminHandler.ListAuditEvents)
 		}
 	}
 	return r
@@ -0,0 +1,634 @@
+package router_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"instagram-backend/app/apptest"
+	"instagram-backend/config"
+	"instagram-backend/jobs"
+	"instagram-backend/models"
+	"instagram-backend/oidc/oidctest"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// fixture is a server with a few users and some content to act on.
+type fixture struct {
+	*apptest.Server
+	admin  *models.User
+	seller *models.User
+	buyer  *models.User
+	// post is the seller's; comment is the buyer's, on post.
+	post    *models.Post
+	comment *models.Comment
+}
+
+func newFixture(t *testing.T, configure ...func(*config.Config)) *fixture {
+	t.Helper()
+	s := apptest.New(t, configure...)
+	f := &fixture{
+		Server: s,
+		admin:  s.CreateAdmin(),
+		seller: s.CreateSeller(),
+		buyer:  s.CreateUser(),
+	}
+	f.post = s.CreatePost(f.seller)
+	f.comment = s.CreateComment(f.post, f.buyer)
+	return f
+}
+
+func v1(format string, args ...interface{}) string {
+	return "/api/v1" + fmt.Sprintf(format, args...)
+}
+
+// withTOTP enables two-factor authentication on a new user.
+func (f *fixture) withTOTP(opts ...func(*models.User)) *models.User {
+	f.T().Helper()
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "test", AccountName: "user"})
+	if err != nil {
+		f.T().Fatal(err)
+	}
+	return f.CreateUser(append(opts, func(u *models.User) {
+		u.TOTPSecret = key.Secret()
+		u.TOTPEnabled = true
+	})...)
+}
+
+func (f *fixture) code(user *models.User) string {
+	f.T().Helper()
+	code, err := totp.GenerateCode(user.TOTPSecret, time.Now())
+	if err != nil {
+		f.T().Fatal(err)
+	}
+	return code
+}
+
+// nextCode is the code for the next time step, which is still accepted
+// after the current code has been used, e.g. to log in.
+func (f *fixture) nextCode(user *models.User) string {
+	f.T().Helper()
+	code, err := totp.GenerateCode(user.TOTPSecret, time.Now().Add(30*time.Second))
+	if err != nil {
+		f.T().Fatal(err)
+	}
+	return code
+}
+
+func unverified(u *models.User) {
+	u.EmailVerified = false
+	u.EmailVerifiedAt = nil
+}
+
+// withOIDC configures a provider backed by a mock issuer.
+func withOIDC(t *testing.T) func(*config.Config) {
+	mock := oidctest.NewProvider("client-id", "client-secret")
+	t.Cleanup(mock.Close)
+	return func(cfg *config.Config) {
+		cfg.OIDC.Providers = []config.OIDCProvider{{
+			Name:         "mock",
+			Issuer:       mock.Issuer(),
+			ClientID:     mock.ClientID,
+			ClientSecret: mock.ClientSecret,
+			RedirectURL:  "http://localhost/api/v1/auth/oidc/mock/callback",
+		}}
+	}
+}
+
+type routeTest struct {
+	// route is the method and path pattern the router registers.
+	route     string
+	configure func(*testing.T) func(*config.Config)
+	do        func(f *fixture) *apptest.Response
+	want      int
+}
+
+// routeTests exercises every route with a request that should succeed.
+var routeTests = []routeTest{
+	{route: "GET /swagger/*any", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get("/swagger/index.html")
+	}},
+	{route: "GET /metrics", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get("/metrics")
+	}},
+	{route: "GET /.well-known/jwks.json", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get("/.well-known/jwks.json")
+	}},
+	{route: "GET /u/*username", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get("/u/" + f.seller.Username)
+	}},
+	{route: "GET /p/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get(fmt.Sprintf("/p/%d", f.post.ID))
+	}},
+
+	// Public routes
+	{route: "POST /api/v1/register", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Post(v1("/register"), map[string]string{
+			"username": "newbuyer",
+			"email":    "newbuyer@example.com",
+			"password": apptest.Password,
+			"name":     "New Buyer",
+			"role":     "buyer",
+		})
+	}},
+	{route: "POST /api/v1/login", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Post(v1("/login"), map[string]string{"email": f.buyer.Email, "password": apptest.Password})
+	}},
+	{route: "POST /api/v1/login/2fa", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		user := f.withTOTP()
+		var challenge struct {
+			MFAToken string `json:"mfaToken"`
+		}
+		f.Anonymous().Post(v1("/login"), map[string]string{"email": user.Email, "password": apptest.Password}).
+			Expect(http.StatusOK).Decode(&challenge)
+		return f.Anonymous().Post(v1("/login/2fa"), map[string]string{"mfaToken": challenge.MFAToken, "code": f.code(user)})
+	}},
+	{route: "GET /api/v1/verify-email", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		user := f.CreateUser(unverified)
+		f.As(user).Post(v1("/verify-email/resend"), nil).Expect(http.StatusOK)
+		messages := f.Outbox.Messages()
+		body := messages[len(messages)-1].Body
+		link := body[strings.Index(body, "http"):]
+		link = link[:strings.Index(link, "\n")]
+		u, err := url.Parse(link)
+		if err != nil {
+			f.T().Fatal(err)
+		}
+		return f.Anonymous().Get(u.RequestURI())
+	}},
+
+	// Social login
+	{route: "GET /api/v1/auth/oidc/providers", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get(v1("/auth/oidc/providers"))
+	}},
+	{route: "GET /api/v1/auth/oidc/:provider/login", configure: withOIDC, want: http.StatusFound, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get(v1("/auth/oidc/mock/login"))
+	}},
+	// A full round trip through the mock provider is covered by the handler
+	// tests; here the callback just has to turn away a forged state.
+	{route: "GET /api/v1/auth/oidc/:provider/callback", configure: withOIDC, want: http.StatusBadRequest, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get(v1("/auth/oidc/mock/callback?code=abc&state=forged"))
+	}},
+	{route: "POST /api/v1/auth/oidc/:provider/callback", configure: withOIDC, want: http.StatusBadRequest, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Post(v1("/auth/oidc/mock/callback?code=abc&state=forged"), "")
+	}},
+
+	// Categories and public profiles
+	{route: "GET /api/v1/categories", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get(v1("/categories"))
+	}},
+	{route: "GET /api/v1/categories/:slug/sellers", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		slug := strings.SplitN(f.seller.Username, "/", 2)[0]
+		return f.Anonymous().Get(v1("/categories/%s/sellers", slug))
+	}},
+	{route: "GET /api/v1/profiles/*username", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.Anonymous().Get(v1("/profiles/%s", f.seller.Username))
+	}},
+
+	// Notices, which suspended users can still reach
+	{route: "GET /api/v1/me/notices", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/me/notices"))
+	}},
+	{route: "POST /api/v1/me/notices/:id/appeal", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		notice := &models.Notice{UserID: f.buyer.ID, Action: "warn", Message: "Be nice"}
+		f.Create(notice)
+		return f.As(f.buyer).Post(v1("/me/notices/%d/appeal", notice.ID), map[string]string{"text": "I was nice"})
+	}},
+
+	// Email verification and two-factor authentication
+	{route: "POST /api/v1/verify-email/resend", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.CreateUser(unverified)).Post(v1("/verify-email/resend"), nil)
+	}},
+	{route: "POST /api/v1/me/2fa/totp/enroll", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Post(v1("/me/2fa/totp/enroll"), nil)
+	}},
+	{route: "POST /api/v1/me/2fa/totp/verify", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.buyer)
+		var enrollment struct {
+			Secret string `json:"secret"`
+		}
+		client.Post(v1("/me/2fa/totp/enroll"), nil).Expect(http.StatusOK).Decode(&enrollment)
+		return client.Post(v1("/me/2fa/totp/verify"), map[string]string{"code": f.code(&models.User{TOTPSecret: enrollment.Secret})})
+	}},
+	{route: "DELETE /api/v1/me/2fa/totp", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		user := f.withTOTP()
+		return f.As(user).Do(http.MethodDelete, v1("/me/2fa/totp"), map[string]string{"password": apptest.Password, "code": f.nextCode(user)})
+	}},
+
+	// API keys
+	{route: "GET /api/v1/me/api-keys", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Get(v1("/me/api-keys"))
+	}},
+	{route: "POST /api/v1/me/api-keys", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Post(v1("/me/api-keys"), map[string]interface{}{"name": "shop", "scopes": []string{models.ScopePostsWrite}})
+	}},
+	{route: "DELETE /api/v1/me/api-keys/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.seller)
+		var key struct {
+			ID uint `json:"id"`
+		}
+		client.Post(v1("/me/api-keys"), map[string]interface{}{"name": "shop", "scopes": []string{models.ScopePostsWrite}}).
+			Expect(http.StatusCreated).Decode(&key)
+		return client.Delete(v1("/me/api-keys/%d", key.ID))
+	}},
+
+	// Sessions
+	{route: "GET /api/v1/me/sessions", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/me/sessions"))
+	}},
+	{route: "DELETE /api/v1/me/sessions", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.Login(f.buyer)
+		return f.As(f.buyer).Delete(v1("/me/sessions"))
+	}},
+	{route: "DELETE /api/v1/me/sessions/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.Login(f.buyer)
+		var other models.Session
+		f.DB.Where("user_id = ?", f.buyer.ID).First(&other)
+		return f.As(f.buyer).Delete(v1("/me/sessions/%d", other.ID))
+	}},
+
+	// Account deletion and data export
+	{route: "DELETE /api/v1/me", want: http.StatusAccepted, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Do(http.MethodDelete, v1("/me"), map[string]string{"confirmUsername": f.buyer.Username, "password": apptest.Password})
+	}},
+	{route: "POST /api/v1/me/deletion/cancel", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.As(f.buyer).Do(http.MethodDelete, v1("/me"), map[string]string{"confirmUsername": f.buyer.Username, "password": apptest.Password}).
+			Expect(http.StatusAccepted)
+		// Deletion signs every device out; signing back in is how it's undone.
+		return f.As(f.buyer).Post(v1("/me/deletion/cancel"), nil)
+	}},
+	{route: "GET /api/v1/me/exports", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/me/exports"))
+	}},
+	{route: "POST /api/v1/me/exports", want: http.StatusAccepted, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.buyer)
+		resp := client.Post(v1("/me/exports"), nil)
+		// Wait for the archive, as a client would, so it isn't still being
+		// written when the test ends.
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			var exports []models.DataExport
+			client.Get(v1("/me/exports")).Expect(http.StatusOK).Decode(&exports)
+			if len(exports) > 0 && exports[0].Status != "pending" {
+				break
+			}
+		}
+		return resp
+	}},
+	{route: "GET /api/v1/me/exports/:id/download", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		export := &models.DataExport{UserID: f.buyer.ID, Status: "pending"}
+		f.Create(export)
+		jobs.BuildExport(context.Background(), f.DB, f.App.Config.Retention, export.ID)
+		return f.As(f.buyer).Get(v1("/me/exports/%d/download", export.ID))
+	}},
+
+	// Users
+	{route: "PUT /api/v1/me/username", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Put(v1("/me/username"), map[string]string{"username": "renamed"})
+	}},
+	{route: "GET /api/v1/users/by-username/*username", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/users/by-username/%s", f.seller.Username))
+	}},
+	{route: "GET /api/v1/users/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/users/%d", f.seller.ID))
+	}},
+	{route: "PUT /api/v1/users/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Put(v1("/users/%d", f.buyer.ID), map[string]string{"name": "Renamed", "bio": "Hello"})
+	}},
+	{route: "GET /api/v1/users/:id/subscribers", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.Subscribe(f.buyer, f.seller)
+		return f.As(f.seller).Get(v1("/users/%d/subscribers", f.seller.ID))
+	}},
+
+	// Subscriptions
+	{route: "POST /api/v1/users/:id/subscribe", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Post(v1("/users/%d/subscribe", f.seller.ID), nil)
+	}},
+	{route: "DELETE /api/v1/users/:id/subscribe", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.Subscribe(f.buyer, f.seller)
+		return f.As(f.buyer).Delete(v1("/users/%d/subscribe", f.seller.ID))
+	}},
+	{route: "PUT /api/v1/me/privacy", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Put(v1("/me/privacy"), map[string]bool{"isPrivate": true})
+	}},
+	{route: "GET /api/v1/me/subscription-requests", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Get(v1("/me/subscription-requests"))
+	}},
+	{route: "POST /api/v1/me/subscription-requests/:id/approve", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		request := &models.Subscription{SubscriberID: f.buyer.ID, SellerID: f.seller.ID, Status: models.SubscriptionPending}
+		f.Create(request)
+		return f.As(f.seller).Post(v1("/me/subscription-requests/%d/approve", request.ID), nil)
+	}},
+	{route: "POST /api/v1/me/subscription-requests/:id/deny", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		request := &models.Subscription{SubscriberID: f.buyer.ID, SellerID: f.seller.ID, Status: models.SubscriptionPending}
+		f.Create(request)
+		return f.As(f.seller).Post(v1("/me/subscription-requests/%d/deny", request.ID), nil)
+	}},
+
+	// Blocking and muting
+	{route: "GET /api/v1/me/blocked", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/me/blocked"))
+	}},
+	{route: "POST /api/v1/me/blocked", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Post(v1("/me/blocked"), map[string]uint{"userId": f.seller.ID})
+	}},
+	{route: "DELETE /api/v1/me/blocked/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.Create(&models.Block{BlockerID: f.buyer.ID, BlockedID: f.seller.ID})
+		return f.As(f.buyer).Delete(v1("/me/blocked/%d", f.seller.ID))
+	}},
+	{route: "GET /api/v1/me/muted", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/me/muted"))
+	}},
+	{route: "POST /api/v1/me/muted", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Post(v1("/me/muted"), map[string]uint{"userId": f.seller.ID})
+	}},
+	{route: "DELETE /api/v1/me/muted/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.Create(&models.Mute{MuterID: f.buyer.ID, MutedID: f.seller.ID})
+		return f.As(f.buyer).Delete(v1("/me/muted/%d", f.seller.ID))
+	}},
+
+	// Reporting and seller verification
+	{route: "POST /api/v1/reports", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Post(v1("/reports"), map[string]interface{}{"targetType": "post", "targetId": f.post.ID, "reason": "spam"})
+	}},
+	{route: "GET /api/v1/me/verification", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		f.Create(&models.VerificationRequest{UserID: f.seller.ID, LegalName: "Shop Ltd", Documents: `["https://example.com/doc.pdf"]`})
+		return f.As(f.seller).Get(v1("/me/verification"))
+	}},
+	{route: "POST /api/v1/me/verification", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Post(v1("/me/verification"), map[string]interface{}{
+			"legalName": "Shop Ltd",
+			"documents": []string{"https://example.com/doc.pdf"},
+		})
+	}},
+
+	// Posts, likes and comments
+	{route: "GET /api/v1/posts", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/posts"))
+	}},
+	{route: "GET /api/v1/posts/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/posts/%d", f.post.ID))
+	}},
+	{route: "POST /api/v1/posts/:id/like", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Post(v1("/posts/%d/like", f.post.ID), nil)
+	}},
+	{route: "DELETE /api/v1/posts/:id/like", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.buyer)
+		client.Post(v1("/posts/%d/like", f.post.ID), nil).Expect(http.StatusCreated)
+		return client.Delete(v1("/posts/%d/like", f.post.ID))
+	}},
+	{route: "POST /api/v1/posts/:id/comments", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Post(v1("/posts/%d/comments", f.post.ID), map[string]string{"content": "Lovely"})
+	}},
+	{route: "GET /api/v1/posts/:id/comments", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Get(v1("/posts/%d/comments", f.post.ID))
+	}},
+	{route: "DELETE /api/v1/posts/:id/comments/:commentId", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.buyer).Delete(v1("/posts/%d/comments/%d", f.post.ID, f.comment.ID))
+	}},
+
+	// Comment review
+	{route: "GET /api/v1/me/held-comments", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Get(v1("/me/held-comments"))
+	}},
+	{route: "POST /api/v1/me/held-comments/:id/approve", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		held := f.CreateComment(f.post, f.buyer, func(c *models.Comment) { c.Status = models.CommentHeld })
+		return f.As(f.seller).Post(v1("/me/held-comments/%d/approve", held.ID), nil)
+	}},
+	{route: "DELETE /api/v1/me/held-comments/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		held := f.CreateComment(f.post, f.buyer, func(c *models.Comment) { c.Status = models.CommentHeld })
+		return f.As(f.seller).Delete(v1("/me/held-comments/%d", held.ID))
+	}},
+	{route: "GET /api/v1/me/blocked-keywords", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Get(v1("/me/blocked-keywords"))
+	}},
+	{route: "POST /api/v1/me/blocked-keywords", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Post(v1("/me/blocked-keywords"), map[string]string{"keyword": "cheap"})
+	}},
+	{route: "DELETE /api/v1/me/blocked-keywords/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		keyword := &models.BlockedKeyword{SellerID: f.seller.ID, Keyword: "cheap"}
+		f.Create(keyword)
+		return f.As(f.seller).Delete(v1("/me/blocked-keywords/%d", keyword.ID))
+	}},
+
+	// Trash
+	{route: "GET /api/v1/me/trash", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Get(v1("/me/trash"))
+	}},
+	{route: "POST /api/v1/me/trash/posts/:id/restore", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.seller)
+		client.Delete(v1("/posts/%d", f.post.ID)).Expect(http.StatusOK)
+		return client.Post(v1("/me/trash/posts/%d/restore", f.post.ID), nil)
+	}},
+	{route: "POST /api/v1/me/trash/comments/:id/restore", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.buyer)
+		client.Delete(v1("/posts/%d/comments/%d", f.post.ID, f.comment.ID)).Expect(http.StatusOK)
+		return client.Post(v1("/me/trash/comments/%d/restore", f.comment.ID), nil)
+	}},
+
+	// Seller integrations
+	{route: "POST /api/v1/posts", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Post(v1("/posts"), map[string]interface{}{
+			"caption":     "New arrivals",
+			"contentType": "feed",
+			"imageUrls":   []string{"https://example.com/new.jpg"},
+		})
+	}},
+	{route: "PUT /api/v1/posts/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Put(v1("/posts/%d", f.post.ID), map[string]string{"caption": "Updated", "contentType": "feed"})
+	}},
+	{route: "DELETE /api/v1/posts/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.seller).Delete(v1("/posts/%d", f.post.ID))
+	}},
+
+	// Admin settings and categories
+	{route: "GET /api/v1/admin/settings/seller-2fa", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Get(v1("/admin/settings/seller-2fa"))
+	}},
+	{route: "PUT /api/v1/admin/settings/seller-2fa", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Put(v1("/admin/settings/seller-2fa"), map[string]bool{"required": true})
+	}},
+	{route: "POST /api/v1/admin/categories", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Post(v1("/admin/categories"), map[string]string{"slug": "shoes", "name": "Shoes"})
+	}},
+
+	// Moderation queue
+	{route: "GET /api/v1/admin/reports", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Get(v1("/admin/reports"))
+	}},
+	{route: "POST /api/v1/admin/reports/:id/assign", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		report := f.report()
+		return f.As(f.admin).Post(v1("/admin/reports/%d/assign", report.ID), nil)
+	}},
+	{route: "POST /api/v1/admin/reports/:id/resolve", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		report := f.report()
+		return f.As(f.admin).Post(v1("/admin/reports/%d/resolve", report.ID), map[string]string{"action": "dismiss"})
+	}},
+	{route: "GET /api/v1/admin/appeals", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Get(v1("/admin/appeals"))
+	}},
+	{route: "POST /api/v1/admin/appeals/:id/resolve", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		now := time.Now()
+		notice := &models.Notice{UserID: f.buyer.ID, Action: "warn", Message: "Be nice", AppealText: "I was nice", AppealStatus: "pending", AppealedAt: &now}
+		f.Create(notice)
+		return f.As(f.admin).Post(v1("/admin/appeals/%d/resolve", notice.ID), map[string]bool{"overturn": true})
+	}},
+
+	// User and content management
+	{route: "GET /api/v1/admin/users", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Get(v1("/admin/users?q=user"))
+	}},
+	{route: "POST /api/v1/admin/users/:id/suspend", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Post(v1("/admin/users/%d/suspend", f.buyer.ID), map[string]interface{}{"days": 7, "reason": "Spam"})
+	}},
+	{route: "DELETE /api/v1/admin/users/:id/suspend", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.admin)
+		client.Post(v1("/admin/users/%d/suspend", f.buyer.ID), map[string]interface{}{"days": 7, "reason": "Spam"}).Expect(http.StatusOK)
+		return client.Delete(v1("/admin/users/%d/suspend", f.buyer.ID))
+	}},
+	{route: "POST /api/v1/admin/users/:id/verify-email", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Post(v1("/admin/users/%d/verify-email", f.CreateUser(unverified).ID), nil)
+	}},
+	{route: "PUT /api/v1/admin/users/:id/role", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Put(v1("/admin/users/%d/role", f.buyer.ID), map[string]string{"role": "admin"})
+	}},
+	{route: "POST /api/v1/admin/users/:id/impersonate", want: http.StatusCreated, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Post(v1("/admin/users/%d/impersonate", f.buyer.ID), map[string]string{"reason": "Support ticket 1"})
+	}},
+	{route: "DELETE /api/v1/admin/posts/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Delete(v1("/admin/posts/%d", f.post.ID))
+	}},
+	{route: "POST /api/v1/admin/posts/:id/restore", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.admin)
+		client.Delete(v1("/admin/posts/%d", f.post.ID)).Expect(http.StatusOK)
+		return client.Post(v1("/admin/posts/%d/restore", f.post.ID), nil)
+	}},
+	{route: "DELETE /api/v1/admin/comments/:id", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Delete(v1("/admin/comments/%d", f.comment.ID))
+	}},
+	{route: "POST /api/v1/admin/comments/:id/restore", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		client := f.As(f.admin)
+		client.Delete(v1("/admin/comments/%d", f.comment.ID)).Expect(http.StatusOK)
+		return client.Post(v1("/admin/comments/%d/restore", f.comment.ID), nil)
+	}},
+
+	// Seller verification review and the audit log
+	{route: "GET /api/v1/admin/verifications", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Get(v1("/admin/verifications"))
+	}},
+	{route: "POST /api/v1/admin/verifications/:id/approve", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Post(v1("/admin/verifications/%d/approve", f.verificationRequest().ID), nil)
+	}},
+	{route: "POST /api/v1/admin/verifications/:id/reject", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Post(v1("/admin/verifications/%d/reject", f.verificationRequest().ID), map[string]string{"reason": "Blurry documents"})
+	}},
+	{route: "GET /api/v1/admin/audit", want: http.StatusOK, do: func(f *fixture) *apptest.Response {
+		return f.As(f.admin).Get(v1("/admin/audit"))
+	}},
+}
+
+func (f *fixture) report() *models.Report {
+	report := &models.Report{ReporterID: f.buyer.ID, TargetType: models.ReportTargetPost, TargetID: f.post.ID, Reason: "spam"}
+	f.Create(report)
+	return report
+}
+
+func (f *fixture) verificationRequest() *models.VerificationRequest {
+	request := &models.VerificationRequest{UserID: f.seller.ID, LegalName: "Shop Ltd", Documents: `["https://example.com/doc.pdf"]`}
+	f.Create(request)
+	return request
+}
+
+func TestRoutes(t *testing.T) {
+	for _, tt := range routeTests {
+		t.Run(tt.route, func(t *testing.T) {
+			var configure []func(*config.Config)
+			if tt.configure != nil {
+				configure = append(configure, tt.configure(t))
+			}
+			f := newFixture(t, configure...)
+			tt.do(f).Expect(tt.want)
+		})
+	}
+}
+
+func TestEveryRouteIsTested(t *testing.T) {
+	tested := map[string]bool{}
+	for _, tt := range routeTests {
+		tested[tt.route] = true
+	}
+
+	var missing []string
+	for _, route := range apptest.New(t).Router.Routes() {
+		if key := route.Method + " " + route.Path; !tested[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("routes without a test:\n%s", strings.Join(missing, "\n"))
+	}
+}
+
+// publicRoutes are reachable without signing in.
+var publicRoutes = map[string]bool{
+	"GET /swagger/*any":                         true,
+	"GET /metrics":                              true,
+	"GET /.well-known/jwks.json":                true,
+	"GET /u/*username":                          true,
+	"GET /p/:id":                                true,
+	"POST /api/v1/register":                     true,
+	"POST /api/v1/login":                        true,
+	"POST /api/v1/login/2fa":                    true,
+	"GET /api/v1/verify-email":                  true,
+	"GET /api/v1/auth/oidc/providers":           true,
+	"GET /api/v1/auth/oidc/:provider/login":     true,
+	"GET /api/v1/auth/oidc/:provider/callback":  true,
+	"POST /api/v1/auth/oidc/:provider/callback": true,
+	"GET /api/v1/categories":                    true,
+	"GET /api/v1/categories/:slug/sellers":      true,
+	"GET /api/v1/profiles/*username":            true,
+}
+
+// concrete fills in a route pattern's parameters.
+func concrete(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "1"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func TestRoutesRequireAuthentication(t *testing.T) {
+	s := apptest.New(t)
+	for _, route := range s.Router.Routes() {
+		if publicRoutes[route.Method+" "+route.Path] {
+			continue
+		}
+		resp := s.Anonymous().Do(route.Method, concrete(route.Path), nil)
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a token: status = %d, want %d", route.Method, route.Path, resp.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	s := apptest.New(t)
+	buyer := s.As(s.CreateUser())
+	for _, route := range s.Router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/admin/") {
+			continue
+		}
+		resp := buyer.Do(route.Method, concrete(route.Path), nil)
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("%s %s as a buyer: status = %d, want %d", route.Method, route.Path, resp.Code, http.StatusForbidden)
+		}
+	}
+}
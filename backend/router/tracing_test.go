@@ -0,0 +1,101 @@
+package router_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"instagram-backend/app/apptest"
+	"instagram-backend/middleware"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	callerTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpanID  = "00f067aa0ba902b7"
+)
+
+func TestTracing(t *testing.T) {
+	s := apptest.New(t)
+	post := s.CreatePost(s.CreateSeller())
+	client := s.As(s.CreateUser())
+	s.Spans.Reset()
+
+	client.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-01", callerTraceID, callerSpanID))
+	requestID := client.Get(fmt.Sprintf("/api/v1/posts/%d", post.ID)).Expect(http.StatusOK).
+		Header().Get(middleware.RequestIDHeader)
+
+	spans := s.Spans.GetSpans()
+	var server *tracetest.SpanStub
+	for i, span := range spans {
+		if span.Name == "/api/v1/posts/:id" {
+			server = &spans[i]
+		}
+	}
+	if server == nil {
+		t.Fatalf("no span for the request among %v", spanNames(spans))
+	}
+	if got := server.SpanContext.TraceID().String(); got != callerTraceID {
+		t.Errorf("request span trace ID = %s, want the caller's %s", got, callerTraceID)
+	}
+	if got := server.Parent.SpanID().String(); got != callerSpanID {
+		t.Errorf("request span parent = %s, want the caller's span %s", got, callerSpanID)
+	}
+
+	// Queries and Redis commands are recorded within the request's trace,
+	// including the middleware's session lookup
+	children := map[string]bool{}
+	for _, span := range spans {
+		if span.SpanContext.TraceID() == server.SpanContext.TraceID() && span.Name != server.Name {
+			children[span.Name] = true
+		} else if strings.HasPrefix(span.Name, "gorm.") {
+			t.Errorf("%s span outside the request's trace", span.Name)
+		}
+	}
+	for _, want := range []string{"gorm.Query", "get", "set"} {
+		if !children[want] {
+			t.Errorf("no %q span in the request's trace, got %v", want, children)
+		}
+	}
+
+	// The request's log lines carry the trace ID
+	logged := false
+	for _, entry := range s.Logs.Entries("Request") {
+		if entry["request_id"] == requestID {
+			logged = true
+			if entry["trace_id"] != callerTraceID {
+				t.Errorf("access log trace_id = %v, want %s", entry["trace_id"], callerTraceID)
+			}
+		}
+	}
+	if !logged {
+		t.Errorf("no access log entry with request_id %q", requestID)
+	}
+}
+
+func TestTracingOmitsQueryValues(t *testing.T) {
+	s := apptest.New(t)
+	user := s.CreateUser()
+	s.Spans.Reset()
+
+	s.Anonymous().Post("/api/v1/login", map[string]string{"email": user.Email, "password": apptest.Password}).
+		Expect(http.StatusOK)
+
+	for _, span := range s.Spans.GetSpans() {
+		for _, attr := range span.Attributes {
+			if value := attr.Value.Emit(); strings.Contains(value, user.Email) {
+				t.Errorf("span %s records %s = %q", span.Name, attr.Key, value)
+			}
+		}
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return names
+}
@@ -0,0 +1,35 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"testing"
+)
+
+// Submitting checks for a pending request before creating one, so only a
+// concurrent submission reaches the database with a second pending request;
+// the database has to refuse it.
+func TestSecondPendingVerificationRefused(t *testing.T) {
+	f := newFixture(t)
+	verifications := f.App.Repositories.Verifications
+
+	first := models.VerificationRequest{UserID: f.seller.ID, LegalName: "Shop Ltd", Status: models.VerificationPending}
+	if err := verifications.Create(context.Background(), &first); err != nil {
+		t.Fatalf("create first request: %v", err)
+	}
+	second := models.VerificationRequest{UserID: f.seller.ID, LegalName: "Shop Ltd", Status: models.VerificationPending}
+	if err := verifications.Create(context.Background(), &second); !errors.Is(err, repository.ErrVerificationPending) {
+		t.Fatalf("create second request: err = %v, want ErrVerificationPending", err)
+	}
+
+	// Once the first is reviewed the seller may apply again.
+	first.Status = models.VerificationRejected
+	if _, err := verifications.Review(context.Background(), &first); err != nil {
+		t.Fatalf("review first request: %v", err)
+	}
+	if err := verifications.Create(context.Background(), &second); err != nil {
+		t.Fatalf("create request after review: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+)
+
+// PostService applies visibility rules to posts and their comments and likes.
+type PostService struct {
+	users    *UserService
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	likes    repository.LikeRepository
+}
+
+// moderationVisible reports whether a post or comment with the given
+// moderation status may be shown to viewerID.
+func moderationVisible(status string, authorID, viewerID uint) bool {
+	switch status {
+	case "":
+		return true
+	case models.ModerationHidden, models.ModerationShadowHidden:
+		return authorID == viewerID
+	default:
+		return false
+	}
+}
+
+// CanView combines the author's visibility with the post's moderation status.
+// post.User must be loaded.
+func (s *PostService) CanView(ctx context.Context, viewerID uint, post *models.Post) (bool, error) {
+	if !moderationVisible(post.ModerationStatus, post.UserID, viewerID) {
+		return false, nil
+	}
+	return s.users.CanViewContent(ctx, viewerID, &post.User)
+}
+
+// Visible loads a post with its author, or returns ErrNotFound if it doesn't
+// exist or viewerID may not see it.
+func (s *PostService) Visible(ctx context.Context, viewerID, postID uint) (*models.Post, error) {
+	post, err := s.posts.FindWithAuthor(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.CanView(ctx, viewerID, post)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return post, nil
+}
+
+// Details loads a post with everything embedded in it. It is the same for
+// every viewer, so it can be cached; call ShowTo before returning it.
+func (s *PostService) Details(ctx context.Context, postID uint) (*models.Post, error) {
+	return s.posts.FindWithDetails(ctx, postID)
+}
+
+// ShowTo prepares a post from Details for viewerID, returning ErrNotFound if
+// they may not see it.
+func (s *PostService) ShowTo(ctx context.Context, viewerID uint, post *models.Post) error {
+	ok, err := s.CanView(ctx, viewerID, post)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return s.HideBlockedComments(ctx, viewerID, post)
+}
+
+// HideBlockedComments drops embedded comments written by users on either side
+// of a block with viewerID.
+func (s *PostService) HideBlockedComments(ctx context.Context, viewerID uint, posts ...*models.Post) error {
+	blocked, err := s.users.users.BlockedWith(ctx, viewerID)
+	if err != nil || len(blocked) == 0 {
+		return err
+	}
+
+	hidden := make(map[uint]bool, len(blocked))
+	for _, id := range blocked {
+		hidden[id] = true
+	}
+	for _, post := range posts {
+		comments := post.Comments[:0]
+		for _, comment := range post.Comments {
+			if !hidden[comment.UserID] {
+				comments = append(comments, comment)
+			}
+		}
+		post.Comments = comments
+	}
+	return nil
+}
+
+// Feed returns a page of the posts viewerID may see, newest first, with the
+// total number of such posts.
+func (s *PostService) Feed(ctx context.Context, viewerID uint, page, pageSize int) ([]models.Post, int64, error) {
+	posts, total, err := s.posts.Feed(ctx, viewerID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	ptrs := make([]*models.Post, len(posts))
+	for i := range posts {
+		ptrs[i] = &posts[i]
+	}
+	if err := s.HideBlockedComments(ctx, viewerID, ptrs...); err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// Comments lists the comments on a post that viewerID may see, newest first.
+func (s *PostService) Comments(ctx context.Context, viewerID, postID uint) ([]models.Comment, error) {
+	if _, err := s.Visible(ctx, viewerID, postID); err != nil {
+		return nil, err
+	}
+	return s.comments.ListVisible(ctx, postID, viewerID)
+}
+
+// AddComment saves a comment, which the caller has already screened, and loads
+// its author.
+func (s *PostService) AddComment(ctx context.Context, comment *models.Comment) error {
+	return s.comments.Create(ctx, comment)
+}
+
+// Like likes a post viewerID may see, once.
+func (s *PostService) Like(ctx context.Context, userID, postID uint) error {
+	if _, err := s.Visible(ctx, userID, postID); err != nil {
+		return err
+	}
+	liked, err := s.likes.Exists(ctx, postID, userID)
+	if err != nil {
+		return err
+	}
+	if liked {
+		return ErrAlreadyLiked
+	}
+	return s.likes.Create(ctx, &models.Like{PostID: postID, UserID: userID})
+}
+
+// Unlike takes back a like.
+func (s *PostService) Unlike(ctx context.Context, userID, postID uint) error {
+	deleted, err := s.likes.Delete(ctx, postID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotLiked
+	}
+	return nil
+}
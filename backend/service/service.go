@@ -0,0 +1,41 @@
+// Package service holds the business rules of posts, comments, likes and
+// subscriptions on top of the repository interfaces, so handlers only deal
+// with HTTP and the rules can be tested against repository.Memory.
+package service
+
+import (
+	"errors"
+	"instagram-backend/repository"
+)
+
+var (
+	// ErrNotFound covers both missing records and ones the caller may not see.
+	ErrNotFound          = repository.ErrNotFound
+	ErrAlreadyLiked      = errors.New("Post already liked")
+	ErrNotLiked          = errors.New("Post not liked")
+	ErrSelfSubscription  = errors.New("You cannot subscribe to yourself")
+	ErrAlreadySubscribed = errors.New("Already subscribed or requested")
+	ErrNotSubscribed     = errors.New("Not subscribed")
+)
+
+// Services groups the services handlers use.
+type Services struct {
+	Users         *UserService
+	Posts         *PostService
+	Subscriptions *SubscriptionService
+}
+
+// New builds the services on top of repos.
+func New(repos *repository.Repositories) *Services {
+	users := &UserService{users: repos.Users, subscriptions: repos.Subscriptions}
+	return &Services{
+		Users: users,
+		Posts: &PostService{
+			users:    users,
+			posts:    repos.Posts,
+			comments: repos.Comments,
+			likes:    repos.Likes,
+		},
+		Subscriptions: &SubscriptionService{users: users, subscriptions: repos.Subscriptions},
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"instagram-backend/models"
+	"instagram-backend/repository"
+
+	"gorm.io/gorm"
+)
+
+// newTestServices seeds a buyer (1), a public seller (2) and a private seller
+// (3), each seller with one post.
+func newTestServices() (*Services, *repository.Memory) {
+	mem := &repository.Memory{
+		Users: []models.User{
+			{Model: gorm.Model{ID: 1}, Username: "buyer", Role: "buyer"},
+			{Model: gorm.Model{ID: 2}, Username: "public", Role: "seller"},
+			{Model: gorm.Model{ID: 3}, Username: "private", Role: "seller", IsPrivate: true},
+		},
+		Posts: []models.Post{
+			{Model: gorm.Model{ID: 10}, UserID: 2},
+			{Model: gorm.Model{ID: 11}, UserID: 3},
+		},
+	}
+	return New(mem.Repositories()), mem
+}
+
+func TestPrivatePostNeedsApprovedSubscription(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestServices()
+
+	if _, err := s.Posts.Visible(ctx, 1, 10); err != nil {
+		t.Fatalf("public post: %v", err)
+	}
+	if _, err := s.Posts.Visible(ctx, 1, 11); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("private post before subscribing: err = %v, want ErrNotFound", err)
+	}
+
+	subscription, err := s.Subscriptions.Subscribe(ctx, 1, 3)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if subscription.Status != models.SubscriptionPending {
+		t.Fatalf("status = %q, want pending", subscription.Status)
+	}
+	if _, err := s.Posts.Visible(ctx, 1, 11); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("private post while pending: err = %v, want ErrNotFound", err)
+	}
+
+	if _, err := s.Subscriptions.Approve(ctx, 3, subscription.ID); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if _, err := s.Posts.Visible(ctx, 1, 11); err != nil {
+		t.Fatalf("private post once approved: %v", err)
+	}
+}
+
+func TestBlockHidesPostsAndUsers(t *testing.T) {
+	ctx := context.Background()
+	s, mem := newTestServices()
+	mem.Blocks = []models.Block{{BlockerID: 2, BlockedID: 1}}
+
+	if _, err := s.Posts.Visible(ctx, 1, 10); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("post by blocker: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Users.Get(ctx, 1, 2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("blocker's profile: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Subscriptions.Subscribe(ctx, 1, 2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("subscribe to blocker: err = %v, want ErrNotFound", err)
+	}
+	posts, total, err := s.Posts.Feed(ctx, 1, 1, 20)
+	if err != nil {
+		t.Fatalf("feed: %v", err)
+	}
+	if total != 0 || len(posts) != 0 {
+		t.Fatalf("feed has %d of %d posts, want none", len(posts), total)
+	}
+}
+
+func TestLikeOnce(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestServices()
+
+	if err := s.Posts.Like(ctx, 1, 10); err != nil {
+		t.Fatalf("like: %v", err)
+	}
+	if err := s.Posts.Like(ctx, 1, 10); !errors.Is(err, ErrAlreadyLiked) {
+		t.Fatalf("second like: err = %v, want ErrAlreadyLiked", err)
+	}
+	if err := s.Posts.Like(ctx, 1, 11); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("like private post: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Posts.Unlike(ctx, 1, 10); err != nil {
+		t.Fatalf("unlike: %v", err)
+	}
+	if err := s.Posts.Unlike(ctx, 1, 10); !errors.Is(err, ErrNotLiked) {
+		t.Fatalf("second unlike: err = %v, want ErrNotLiked", err)
+	}
+}
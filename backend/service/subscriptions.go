@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+	"time"
+)
+
+// SubscriptionService handles subscribing to sellers and private sellers'
+// approval of requests.
+type SubscriptionService struct {
+	users         *UserService
+	subscriptions repository.SubscriptionRepository
+}
+
+// Subscribe subscribes userID to a seller. Subscriptions to private sellers
+// stay pending until approved. If there already is one, it is returned with
+// ErrAlreadySubscribed.
+func (s *SubscriptionService) Subscribe(ctx context.Context, userID, sellerID uint) (*models.Subscription, error) {
+	seller, err := s.users.Get(ctx, userID, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if seller.Role != "seller" {
+		return nil, ErrNotFound
+	}
+	if seller.ID == userID {
+		return nil, ErrSelfSubscription
+	}
+
+	existing, err := s.subscriptions.Find(ctx, userID, seller.ID)
+	if err == nil {
+		return existing, ErrAlreadySubscribed
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	subscription := &models.Subscription{
+		SubscriberID: userID,
+		SellerID:     seller.ID,
+		Status:       models.SubscriptionApproved,
+	}
+	if seller.IsPrivate {
+		subscription.Status = models.SubscriptionPending
+	} else {
+		now := time.Now()
+		subscription.ApprovedAt = &now
+	}
+	if err := s.subscriptions.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Unsubscribe ends a subscription or withdraws a pending request.
+func (s *SubscriptionService) Unsubscribe(ctx context.Context, userID, sellerID uint) error {
+	deleted, err := s.subscriptions.Delete(ctx, userID, sellerID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotSubscribed
+	}
+	return nil
+}
+
+// Requests lists the requests awaiting sellerID's approval, oldest first.
+func (s *SubscriptionService) Requests(ctx context.Context, sellerID uint) ([]models.Subscription, error) {
+	return s.subscriptions.ListPending(ctx, sellerID)
+}
+
+// Approve approves one of sellerID's pending requests.
+func (s *SubscriptionService) Approve(ctx context.Context, sellerID, id uint) (*models.Subscription, error) {
+	subscription, err := s.subscriptions.FindPending(ctx, id, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.subscriptions.Approve(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Deny turns down one of sellerID's pending requests. The requester may ask
+// again.
+func (s *SubscriptionService) Deny(ctx context.Context, sellerID, id uint) error {
+	subscription, err := s.subscriptions.FindPending(ctx, id, sellerID)
+	if err != nil {
+		return err
+	}
+	return s.subscriptions.Deny(ctx, subscription)
+}
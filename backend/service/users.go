@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"instagram-backend/models"
+	"instagram-backend/repository"
+)
+
+// UserService decides who may see whom.
+type UserService struct {
+	users         repository.UserRepository
+	subscriptions repository.SubscriptionRepository
+}
+
+// Get returns a user, or ErrNotFound if they and viewerID have blocked each
+// other.
+func (s *UserService) Get(ctx context.Context, viewerID, id uint) (*models.User, error) {
+	if err := s.CheckNotBlocked(ctx, viewerID, id); err != nil {
+		return nil, err
+	}
+	return s.users.FindByID(ctx, id)
+}
+
+// CheckNotBlocked returns ErrNotFound if either user has blocked the other:
+// blocked users look to each other as if they didn't exist.
+func (s *UserService) CheckNotBlocked(ctx context.Context, viewerID, id uint) error {
+	blocked, err := s.users.IsBlocked(ctx, viewerID, id)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CanViewContent reports whether viewerID may see owner's posts and comments:
+// their own, a public account's, or a private account's they are an approved
+// subscriber of, unless either has blocked the other.
+func (s *UserService) CanViewContent(ctx context.Context, viewerID uint, owner *models.User) (bool, error) {
+	if owner.ID == viewerID {
+		return true, nil
+	}
+	blocked, err := s.users.IsBlocked(ctx, viewerID, owner.ID)
+	if err != nil || blocked {
+		return false, err
+	}
+	if !owner.IsPrivate {
+		return true, nil
+	}
+	return s.subscriptions.IsApproved(ctx, viewerID, owner.ID)
+}
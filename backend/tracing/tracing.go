@@ -0,0 +1,89 @@
+// Package tracing sets up OpenTelemetry tracing. Spans cover incoming
+// requests, database queries and Redis commands, and join traces started by
+// callers through the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// Options configures NewProvider.
+type Options struct {
+	// Exporter is "none" or "otlp".
+	Exporter string
+	// Endpoint is the OTLP/HTTP traces URL; empty uses the OTEL_EXPORTER_OTLP_*
+	// variables.
+	Endpoint    string
+	ServiceName string
+	Environment string
+	SampleRatio float64
+}
+
+// NewProvider returns the tracer provider opts select, and a function that
+// flushes and stops it. With no exporter spans are neither recorded nor sent.
+func NewProvider(ctx context.Context, opts Options) (trace.TracerProvider, func(context.Context) error, error) {
+	if opts.Exporter != "otlp" {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	var exporterOpts []otlptracehttp.Option
+	if opts.Endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(Resource(opts.ServiceName, opts.Environment)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	return tp, tp.Shutdown, nil
+}
+
+// Resource describes this service to the tracing backend.
+func Resource(serviceName, environment string) *resource.Resource {
+	return resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("deployment.environment", environment),
+	)
+}
+
+// Propagator reads and writes W3C traceparent and baggage headers.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// InstrumentDB records a span for each query db runs. Queries are recorded
+// with placeholders rather than their values, which may be secrets.
+func InstrumentDB(db *gorm.DB, tp trace.TracerProvider) error {
+	return db.Use(gormtracing.NewPlugin(
+		gormtracing.WithTracerProvider(tp),
+		gormtracing.WithoutQueryVariables(),
+		gormtracing.WithoutMetrics(),
+	))
+}
+
+// InstrumentRedis records a span for each command rdb runs, named after the
+// command but without its arguments, which hold cached user data.
+func InstrumentRedis(rdb *redis.Client, tp trace.TracerProvider) error {
+	return redisotel.InstrumentTracing(rdb,
+		redisotel.WithTracerProvider(tp),
+		redisotel.WithDBStatement(false),
+	)
+}